@@ -27,6 +27,7 @@ func newReportBuildCmd(out io.Writer) *cobra.Command {
 
 	cmd.AddCommand(
 		newReportBuildAsTableCmd(client, out),
+		newReportBuildAsJSONCmd(client, out),
 		newReportBuildAsTemplateCmd(client, out),
 		newReportBuildAsSaveCmd(client, out),
 		newReportBuildAsEmailCmd(client, out),
@@ -36,7 +37,8 @@ func newReportBuildCmd(out io.Writer) *cobra.Command {
 	dateDefault := time.Now().AddDate(0, 0, -1).Format("02/01/2006")
 
 	flags.StringVar(&client.Filter.Schedule, "schedule", dateDefault, "Schedule title")
-	flags.StringVar(&client.Filter.Pipelines, "filter_pipeline", "", "Select only some pipelines")
+	flags.StringSliceVar(&client.Filter.Pipelines, "filter_pipeline", []string{}, "Select only some pipelines: globs on full name, \"!\" prefix to exclude, \"re:\" prefix for regex")
+	flags.StringVar(&client.Filter.Owner, "owner", "", "Select only pipelines of this owner (name or email)")
 	flags.StringSliceVarP(&client.Filter.Status, "filter_status", "", []string{}, "Select only some level (success/warning/error)")
 
 	return cmd
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/datatok/tintin/pkg/action"
+	"github.com/datatok/tintin/pkg/reporting"
 	"github.com/datatok/tintin/pkg/reporting/output"
 	"github.com/datatok/tintin/pkg/reporting/sender"
 )
@@ -17,6 +19,11 @@ Send report as HTML via email.
 `
 
 func newReportBuildAsEmailCmd(client *action.ReportBuild, out io.Writer) *cobra.Command {
+	var (
+		perOwner bool
+		roles    []string
+	)
+
 	s := &sender.EmailSender{}
 	e := sender.Email{}
 
@@ -25,23 +32,43 @@ func newReportBuildAsEmailCmd(client *action.ReportBuild, out io.Writer) *cobra.
 		Short: buildTemplateEmailHelp,
 		Long:  buildTemplateEmailHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			report := client.Run()
+			report, err := client.Run()
+
+			// Data source errors: send works that could be checked, then fail
+			if report == nil {
+				return err
+			}
 
-			r := bytes.NewBufferString("")
+			e.From = "@todo"
 
-			t := output.NewReportHTML(settings.ReportHTMLTemplatePath, report)
+			if !perOwner {
+				e.Title = fmt.Sprintf("Djobi report %s", report.Title)
+				e.Body = reportToEmailBody(report)
 
-			t.ShowWorkLinks = false
+				s.Send(e)
 
-			t.ToHTML(r)
+				return err
+			}
 
-			e.From = "@todo"
-			e.Title = fmt.Sprintf("Djobi report %s", report.Title)
-			e.Body = r.String()
+			// Each owner gets failing works of its pipelines
+			for _, email := range report.OwnersEmails(roles) {
+				ownerReport := report.FilterFailingByOwner(email, roles)
+
+				if len(ownerReport.Pipelines) == 0 {
+					logrus.Debugf("nothing failing for %s", email)
+					continue
+				}
+
+				ownerReport.Link.Arguments.Owner = email
+
+				e.To = email
+				e.Title = fmt.Sprintf("Djobi report %s - your failing pipelines", report.Filter.Schedule)
+				e.Body = reportToEmailBody(ownerReport)
 
-			s.Send(e)
+				s.Send(e)
+			}
 
-			return nil
+			return err
 		},
 	}
 
@@ -49,6 +76,20 @@ func newReportBuildAsEmailCmd(client *action.ReportBuild, out io.Writer) *cobra.
 
 	f.StringVar(&s.Server, "server", "", "SMTP server")
 	f.StringVar(&e.To, "to", "", "Recipients, comma separated")
+	f.BoolVar(&perOwner, "per-owner", false, "Send each pipeline owner its failing works only (ignore --to)")
+	f.StringSliceVar(&roles, "role", []string{}, "With --per-owner, only owners having one of these roles")
 
 	return cmd
 }
+
+func reportToEmailBody(report *reporting.Report) string {
+	r := bytes.NewBufferString("")
+
+	t := output.NewReportHTML(settings.ReportHTMLTemplatePath, report)
+
+	t.ShowWorkLinks = false
+
+	t.ToHTML(r)
+
+	return r.String()
+}
@@ -21,11 +21,16 @@ func newReportBuildAsTemplateCmd(client *action.ReportBuild, out io.Writer) *cob
 		Short:   buildTemplateHelp,
 		Long:    buildTemplateHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			report := client.Run()
+			report, err := client.Run()
+
+			// Data source errors: render works that could be checked, then fail
+			if report == nil {
+				return err
+			}
 
 			output.NewReportHTML(settings.ReportHTMLTemplatePath, report).ToHTML(out)
 
-			return nil
+			return err
 		},
 	}
 
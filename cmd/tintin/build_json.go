@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/datatok/tintin/pkg/action"
+	"github.com/datatok/tintin/pkg/reporting/output"
+)
+
+const buildJSONHelp = `
+Generate the report as JSON, from Djobi jobs / stages log.
+`
+
+func newReportBuildAsJSONCmd(client *action.ReportBuild, out io.Writer) *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   "json",
+		Short: buildJSONHelp,
+		Long:  buildJSONHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := client.Run()
+
+			// Data source errors: render works that could be checked, then fail
+			if report == nil {
+				return err
+			}
+
+			if errJSON := output.ToJSON(out, report); errJSON != nil {
+				return errJSON
+			}
+
+			return err
+		},
+	}
+
+	return cmd
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/datatok/tintin/pkg/action"
 	"github.com/datatok/tintin/pkg/metrics"
+	"github.com/datatok/tintin/pkg/pipelines"
 	"github.com/datatok/tintin/pkg/reporting/sender"
 )
 
@@ -22,7 +23,7 @@ func sendMetricsCmd(client *action.ReportBuild, out io.Writer) *cobra.Command {
 		Short: buildTemplateMetricsHelp,
 		Long:  buildTemplateMetricsHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			metrics.New(settings).Push(e.To)
+			metrics.New(settings, pipelines.NewRepository(settings)).Push(e.To)
 
 			return nil
 		},
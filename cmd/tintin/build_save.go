@@ -20,9 +20,20 @@ func newReportBuildAsSaveCmd(client *action.ReportBuild, out io.Writer) *cobra.C
 		Short: buildSaveHelp,
 		Long:  buildSaveHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			report := client.Run()
+			report, err := client.Run()
 
-			output.NewStore().SaveReport(report)
+			// Partial reports (data source errors) are not saved
+			if err != nil {
+				return err
+			}
+
+			store, err := output.NewStore(settings)
+
+			if err != nil {
+				return err
+			}
+
+			store.SaveReport(report)
 
 			return nil
 		},
@@ -20,11 +20,16 @@ func newReportBuildAsTableCmd(client *action.ReportBuild, out io.Writer) *cobra.
 		Short: buildTableHelp,
 		Long:  buildTableHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			report := client.Run()
+			report, err := client.Run()
+
+			// Data source errors: render works that could be checked, then fail
+			if report == nil {
+				return err
+			}
 
 			output.ToTable(out, report)
 
-			return nil
+			return err
 		},
 	}
 
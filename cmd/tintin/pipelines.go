@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+const pipelinesHelp = `
+Inspect pipelines definitions, without Djobi logs.
+`
+
+func newPipelinesCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pipelines",
+		Short: pipelinesHelp,
+		Long:  pipelinesHelp,
+	}
+
+	cmd.AddCommand(
+		newPipelinesListCmd(out),
+		newPipelinesShowCmd(out),
+		newPipelinesValidateCmd(out),
+	)
+
+	return cmd
+}
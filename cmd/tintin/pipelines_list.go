@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/datatok/tintin/pkg/action"
+)
+
+const pipelinesListHelp = `
+List discovered pipelines, with jobs, contexts and enabled stages.
+`
+
+func newPipelinesListCmd(out io.Writer) *cobra.Command {
+	client := action.NewPipelinesList(settings)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List pipelines",
+		Long:    pipelinesListHelp,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.Run(out)
+		},
+	}
+
+	f := cmd.Flags()
+
+	f.StringVar(&client.Team, "team", "", "Select only pipelines of this team")
+	f.StringVar(&client.PathRegex, "path_regex", "", "Select only pipelines with path matching this regex")
+	f.BoolVar(&client.Enabled, "enabled", false, "Select only pipelines with reporting enabled")
+	f.BoolVar(&client.Disabled, "disabled", false, "Select only pipelines with reporting disabled")
+	f.StringVarP(&client.Output, "output", "o", client.Output, "Output format: table, json or yaml")
+
+	return cmd
+}
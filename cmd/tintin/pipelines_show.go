@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/datatok/tintin/pkg/action"
+)
+
+const pipelinesShowHelp = `
+Show the normalised definition of a pipeline, by its full name (like "team_a/conso"):
+default contexts and resolved stage kinds included.
+`
+
+func newPipelinesShowCmd(out io.Writer) *cobra.Command {
+	client := action.NewPipelinesShow(settings)
+
+	cmd := &cobra.Command{
+		Use:   "show <fullName>",
+		Short: "Show a pipeline definition",
+		Long:  pipelinesShowHelp,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.Run(out, args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&client.Output, "output", "o", client.Output, "Output format: table, json or yaml")
+
+	return cmd
+}
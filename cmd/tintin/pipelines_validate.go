@@ -0,0 +1,35 @@
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/datatok/tintin/pkg/action"
+)
+
+const pipelinesValidateHelp = `
+Validate pipelines definitions against the schema (pkg/pipelines/pipeline.schema.json).
+Validate the given local directory, or all configured sources.
+Exit with non-zero status if a definition is invalid.
+`
+
+func newPipelinesValidateCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [path]",
+		Short: "Validate pipelines definitions",
+		Long:  pipelinesValidateHelp,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			return action.NewPipelinesValidate(settings).Run(out, path)
+		},
+	}
+
+	return cmd
+}
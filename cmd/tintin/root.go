@@ -23,6 +23,16 @@ func newRootCmd(out io.Writer, args []string) *cobra.Command {
 			}
 
 			logrus.SetLevel(l)
+
+			if logrus.IsLevelEnabled(logrus.DebugLevel) {
+				fields := logrus.Fields{}
+
+				for name, value := range settings.EnvVars() {
+					fields[name] = value
+				}
+
+				logrus.WithFields(fields).Debug("Settings")
+			}
 		},
 	}
 
@@ -31,6 +41,7 @@ func newRootCmd(out io.Writer, args []string) *cobra.Command {
 	cmd.AddCommand(
 		newReportBuildCmd(out),
 		newWebServerCmd(out),
+		newPipelinesCmd(out),
 	)
 
 	settings.AddFlags(flags)
@@ -7,6 +7,7 @@ require (
 	github.com/aws/aws-sdk-go v1.34.19
 	github.com/dustin/go-humanize v1.0.0
 	github.com/elastic/go-elasticsearch/v7 v7.16.0
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-git/go-git/v5 v5.4.2
 	github.com/google/uuid v1.1.1
 	github.com/jordan-wright/email v0.0.0-20190819015918-041e0cec78b0
@@ -18,6 +19,7 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/ulule/deepcopier v0.0.0-20200430083143-45decc6639b6
 	gopkg.in/yaml.v2 v2.3.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -51,5 +53,4 @@ require (
 	golang.org/x/sys v0.0.0-20210502180810-71e4cd670f79 // indirect
 	google.golang.org/protobuf v1.23.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -0,0 +1,125 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+type PipelinesList struct {
+	Team, PathRegex   string
+	Enabled, Disabled bool
+	Output            string
+
+	settings *cli.EnvSettings
+}
+
+func NewPipelinesList(s *cli.EnvSettings) *PipelinesList {
+	return &PipelinesList{
+		Output:   OutputTable,
+		settings: s,
+	}
+}
+
+/**
+ * Find definitions matching filters, sorted by full name (no Djobi logs involved).
+ */
+func (p *PipelinesList) Find() ([]pipelines.Definition, error) {
+	var (
+		ret     []pipelines.Definition
+		pathReg *regexp.Regexp
+		err     error
+	)
+
+	if len(p.PathRegex) > 0 {
+		if pathReg, err = regexp.Compile(p.PathRegex); err != nil {
+			return nil, fmt.Errorf("invalid path regex: %w", err)
+		}
+	}
+
+	definitions, err := pipelines.NewRepository(p.settings).FindAllDefinitions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, definition := range definitions {
+		if len(p.Team) > 0 && p.Team != definition.Team {
+			continue
+		}
+
+		if pathReg != nil && !pathReg.MatchString(definition.Path) {
+			continue
+		}
+
+		if (p.Enabled && !definition.Reporting.Enabled) || (p.Disabled && definition.Reporting.Enabled) {
+			continue
+		}
+
+		ret = append(ret, definition)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].FullName < ret[j].FullName
+	})
+
+	return ret, nil
+}
+
+func (p *PipelinesList) Run(out io.Writer) error {
+	definitions, err := p.Find()
+
+	if err != nil {
+		return err
+	}
+
+	summaries := []PipelineSummary{}
+
+	for _, definition := range definitions {
+		summaries = append(summaries, NewPipelineSummary(definition))
+	}
+
+	return WriteOutput(out, p.Output, summaries, func(out io.Writer) {
+		table := tablewriter.NewWriter(out)
+		table.SetHeader([]string{"Pipeline", "Team", "Enabled", "Jobs", "Contexts", "Enabled stages", "Source"})
+		table.SetAutoWrapText(false)
+
+		for _, summary := range summaries {
+			enabled := fmt.Sprint(summary.Enabled)
+
+			if len(summary.Errors) > 0 {
+				enabled = "invalid"
+			}
+
+			table.Append([]string{
+				summary.Pipeline,
+				summary.Team,
+				enabled,
+				fmt.Sprint(summary.Jobs),
+				fmt.Sprint(summary.Contexts),
+				strings.Join(summary.EnabledStages, ", "),
+				sourceLink(summary.Link, summary.Source),
+			})
+		}
+
+		table.Render()
+	})
+}
+
+/**
+ * Link to definition, or source URL when there is no link template.
+ */
+func sourceLink(link, source string) string {
+	if len(link) == 0 || strings.HasPrefix(link, "__NOT_FOUND__") {
+		return source
+	}
+
+	return link
+}
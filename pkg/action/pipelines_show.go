@@ -0,0 +1,118 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/datatok/tintin/pkg/engine"
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+type PipelinesShow struct {
+	Output string
+
+	settings *cli.EnvSettings
+}
+
+func NewPipelinesShow(s *cli.EnvSettings) *PipelinesShow {
+	return &PipelinesShow{
+		Output:   OutputTable,
+		settings: s,
+	}
+}
+
+/**
+ * Find definition by its full name, like "team_a/conso".
+ */
+func (p *PipelinesShow) Find(fullName string) (*pipelines.Definition, error) {
+	definitions, err := pipelines.NewRepository(p.settings).FindAllDefinitions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, definition := range definitions {
+		if definition.FullName == strings.Trim(fullName, "/") {
+			return &definition, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pipeline %q not found", fullName)
+}
+
+func (p *PipelinesShow) Run(out io.Writer, fullName string) error {
+	definition, err := p.Find(fullName)
+
+	if err != nil {
+		return err
+	}
+
+	kinds, err := engine.LoadStageKinds(p.settings.StageKindsPath)
+
+	if err != nil {
+		return err
+	}
+
+	view := NewPipelineView(*definition, kinds)
+
+	return WriteOutput(out, p.Output, view, func(out io.Writer) {
+		fmt.Fprintf(out, "Pipeline: %s\nTeam:     %s\nEnabled:  %t\nPath:     %s\nSource:   %s\n", view.FullName, view.Team, view.Enabled, view.Path, sourceLink(view.Link, view.Source))
+
+		if len(view.Revision) > 0 {
+			fmt.Fprintf(out, "Revision: %s\n", view.Revision)
+		}
+
+		if view.Schedule != nil {
+			fmt.Fprintf(out, "Schedule: %s\n", view.Schedule)
+		}
+
+		if len(view.Rules) > 0 {
+			fmt.Fprintf(out, "Rules:    %s\n", view.Rules)
+		}
+
+		for _, owner := range view.Owners {
+			fmt.Fprintf(out, "Owner:    %s <%s> %s\n", owner.Name, owner.Email, owner.Role)
+		}
+
+		for _, e := range view.Errors {
+			fmt.Fprintf(out, "Error:    %s\n", e)
+		}
+
+		for _, w := range view.Warnings {
+			fmt.Fprintf(out, "Warning:  %s\n", w)
+		}
+
+		table := tablewriter.NewWriter(out)
+		table.SetHeader([]string{"Job", "Contexts", "Stage", "Kind", "Resolved kind", "Enabled", "Output"})
+
+		for _, job := range view.Jobs {
+			var contexts []string
+
+			for _, context := range job.Contexts {
+				contexts = append(contexts, context.Name+" ("+context.Type+")")
+			}
+
+			jobName, jobContexts := job.Name, strings.Join(contexts, ", ")
+
+			for _, stage := range job.Stages {
+				table.Append([]string{
+					jobName,
+					jobContexts,
+					stage.Name,
+					stage.Kind,
+					stage.ResolvedKind,
+					fmt.Sprint(stage.Enabled),
+					fmt.Sprint(stage.Output),
+				})
+
+				jobName, jobContexts = "", ""
+			}
+		}
+
+		table.Render()
+	})
+}
@@ -0,0 +1,63 @@
+package action
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+type PipelinesValidate struct {
+	settings *cli.EnvSettings
+}
+
+func NewPipelinesValidate(s *cli.EnvSettings) *PipelinesValidate {
+	return &PipelinesValidate{
+		settings: s,
+	}
+}
+
+/**
+ * Validate all definitions of the local path, or of configured sources if path is empty.
+ * Print each error & warning with file & line, and fail if any definition is invalid.
+ */
+func (p *PipelinesValidate) Run(out io.Writer, path string) error {
+	settings := *p.settings
+
+	if len(path) > 0 {
+		settings.PipelinesURLs = []string{"file://" + path}
+	}
+
+	definitions, err := pipelines.NewRepository(&settings).FindAllDefinitions()
+
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+
+	for _, definition := range definitions {
+		for _, w := range definition.Warnings {
+			fmt.Fprintf(out, "warning: %s\n", w.Error())
+		}
+
+		if definition.IsValid() {
+			continue
+		}
+
+		invalid++
+
+		for _, e := range definition.Errors {
+			fmt.Fprintln(out, e.Error())
+		}
+	}
+
+	fmt.Fprintf(out, "%d pipelines, %d invalid\n", len(definitions), invalid)
+
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid pipelines", invalid)
+	}
+
+	return nil
+}
@@ -0,0 +1,267 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/datatok/tintin/pkg/engine"
+	"github.com/datatok/tintin/pkg/pipelines"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+/**
+ * Pipeline summary, for "pipelines list".
+ */
+type PipelineSummary struct {
+	Pipeline      string   `json:"pipeline" yaml:"pipeline"`
+	Team          string   `json:"team" yaml:"team"`
+	Enabled       bool     `json:"enabled" yaml:"enabled"`
+	Jobs          int      `json:"jobs" yaml:"jobs"`
+	Contexts      int      `json:"contexts" yaml:"contexts"`
+	EnabledStages []string `json:"enabled_stages" yaml:"enabled_stages"`
+	Source        string   `json:"source" yaml:"source"`
+	Link          string   `json:"link" yaml:"link"`
+	Errors        []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+/**
+ * Normalised pipeline definition, for "pipelines show".
+ */
+type PipelineView struct {
+	FullName   string                        `json:"full_name" yaml:"full_name"`
+	ID         string                        `json:"id,omitempty" yaml:"id,omitempty"`
+	Name       string                        `json:"name" yaml:"name"`
+	Team       string                        `json:"team" yaml:"team"`
+	Path       string                        `json:"path" yaml:"path"`
+	Source     string                        `json:"source" yaml:"source"`
+	Revision   string                        `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Link       string                        `json:"link" yaml:"link"`
+	Enabled    bool                          `json:"enabled" yaml:"enabled"`
+	Rules      string                        `json:"status_rules,omitempty" yaml:"status_rules,omitempty"`
+	PathLabels map[string]string             `json:"path_labels,omitempty" yaml:"path_labels,omitempty"`
+	Schedule   *pipelines.ScheduleDefinition `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Owners     []OwnerView                   `json:"owners,omitempty" yaml:"owners,omitempty"`
+	Jobs       []JobView                     `json:"jobs" yaml:"jobs"`
+	Errors     []string                      `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Warnings   []string                      `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+type OwnerView struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+	Role  string `json:"role,omitempty" yaml:"role,omitempty"`
+}
+
+type JobView struct {
+	Name     string                        `json:"name" yaml:"name"`
+	Schedule *pipelines.ScheduleDefinition `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Contexts []ContextView                 `json:"contexts" yaml:"contexts"`
+	Stages   []StageView                   `json:"stages" yaml:"stages"`
+}
+
+type ContextView struct {
+	Name     string            `json:"name" yaml:"name"`
+	Type     string            `json:"type" yaml:"type"`
+	WorkName string            `json:"work_name" yaml:"work_name"`
+	Values   map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+type StageView struct {
+	Name         string `json:"name" yaml:"name"`
+	Kind         string `json:"kind" yaml:"kind"`
+	ResolvedKind string `json:"resolved_kind" yaml:"resolved_kind"`
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	Output       bool   `json:"output" yaml:"output"`
+	MinValue     *int64 `json:"min_value,omitempty" yaml:"min_value,omitempty"`
+	MaxValue     *int64 `json:"max_value,omitempty" yaml:"max_value,omitempty"`
+	Unit         string `json:"unit,omitempty" yaml:"unit,omitempty"`
+	AllowEmpty   bool   `json:"allow_empty,omitempty" yaml:"allow_empty,omitempty"`
+}
+
+func NewPipelineSummary(definition pipelines.Definition) PipelineSummary {
+	summary := PipelineSummary{
+		Pipeline:      definition.FullName,
+		Team:          definition.Team,
+		Enabled:       definition.Reporting.Enabled,
+		Jobs:          len(definition.Jobs),
+		Source:        definition.Source,
+		Link:          definition.GitlabLink,
+		Errors:        errorsAsStrings(definition.Errors),
+		EnabledStages: []string{},
+	}
+
+	for _, job := range sortedJobs(definition) {
+		summary.Contexts += len(job.Contexts)
+
+		for _, name := range sortedStageNames(job.Stages) {
+			if job.Stages[name].IsEnabled() {
+				summary.EnabledStages = append(summary.EnabledStages, job.Name+"."+name)
+			}
+		}
+	}
+
+	return summary
+}
+
+func NewPipelineView(definition pipelines.Definition, kinds engine.StageKinds) PipelineView {
+	view := PipelineView{
+		FullName:   definition.FullName,
+		ID:         definition.Meta.ID,
+		Name:       definition.Name,
+		Team:       definition.Team,
+		Path:       definition.Path,
+		Source:     definition.Source,
+		Revision:   definition.Revision,
+		Link:       definition.GitlabLink,
+		Enabled:    definition.Reporting.Enabled,
+		Rules:      definition.Reporting.StatusRules,
+		PathLabels: definition.PathLabels,
+		Schedule:   definition.Schedule,
+		Jobs:       []JobView{},
+		Errors:     errorsAsStrings(definition.Errors),
+		Warnings:   errorsAsStrings(definition.Warnings),
+	}
+
+	for _, owner := range definition.Meta.Owners {
+		view.Owners = append(view.Owners, OwnerView{Name: owner.Name, Email: owner.Email, Role: owner.Role})
+	}
+
+	for _, job := range sortedJobs(definition) {
+		jobView := JobView{
+			Name:     job.Name,
+			Schedule: job.Schedule,
+			Contexts: []ContextView{},
+			Stages:   []StageView{},
+		}
+
+		for _, name := range sortedContextNames(job.Contexts) {
+			context := job.Contexts[name]
+
+			jobView.Contexts = append(jobView.Contexts, ContextView{
+				Name:     name,
+				Type:     context.Type,
+				WorkName: job.ContextWorkName(context),
+				Values:   context.Values,
+			})
+		}
+
+		for _, name := range sortedStageNames(job.Stages) {
+			stage := job.Stages[name]
+
+			jobView.Stages = append(jobView.Stages, StageView{
+				Name:         name,
+				Kind:         stage.Kind,
+				ResolvedKind: kinds.Resolve(stage.Kind),
+				Enabled:      stage.IsEnabled(),
+				Output:       stage.IsOutputStage(),
+				MinValue:     stage.MinValue,
+				MaxValue:     stage.MaxValue,
+				Unit:         stage.Unit,
+				AllowEmpty:   stage.AllowEmpty,
+			})
+		}
+
+		view.Jobs = append(view.Jobs, jobView)
+	}
+
+	return view
+}
+
+/**
+ * Write value as JSON or YAML, or call table writer.
+ */
+func WriteOutput(out io.Writer, format string, v interface{}, table func(out io.Writer)) error {
+	switch format {
+	case OutputTable, "":
+		table(out)
+
+	case OutputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(v)
+
+	case OutputYAML:
+		dat, err := yaml.Marshal(v)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = out.Write(dat)
+
+		return err
+
+	default:
+		return fmt.Errorf("invalid output %q, expected %s, %s or %s", format, OutputTable, OutputJSON, OutputYAML)
+	}
+
+	return nil
+}
+
+func sortedJobs(definition pipelines.Definition) []pipelines.JobDefinition {
+	names := make([]string, 0, len(definition.Jobs))
+
+	for name := range definition.Jobs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var ret []pipelines.JobDefinition
+
+	for _, name := range names {
+		ret = append(ret, definition.Jobs[name])
+	}
+
+	return ret
+}
+
+func errorsAsStrings(errors []pipelines.ValidationError) []string {
+	var ret []string
+
+	for _, err := range errors {
+		ret = append(ret, err.Error())
+	}
+
+	return ret
+}
+
+/**
+ * Sorted names of job stages.
+ */
+func sortedStageNames(stages map[string]pipelines.StageDefinition) []string {
+	ret := make([]string, 0, len(stages))
+
+	for name := range stages {
+		ret = append(ret, name)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+/**
+ * Sorted names of job contexts.
+ */
+func sortedContextNames(contexts map[string]pipelines.JobContextDefinition) []string {
+	ret := make([]string, 0, len(contexts))
+
+	for name := range contexts {
+		ret = append(ret, name)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/datatok/tintin/pkg/reporting"
 	"github.com/datatok/tintin/pkg/utils"
 	"github.com/datatok/tintin/pkg/utils/cli"
-	"github.com/sirupsen/logrus"
 )
 
 type ReportBuild struct {
@@ -20,19 +19,31 @@ func NewBuildReport(s *cli.EnvSettings) *ReportBuild {
 	}
 }
 
-func (p *ReportBuild) Run() *reporting.Report {
-	pp, err := pipelines.NewRepository(p.settings).FindDefinitions(p.Filter)
+/**
+ * Build the report. On data source errors, the report is returned with the error,
+ * its affected works are DATA_UNAVAILABLE.
+ */
+func (p *ReportBuild) Run() (*reporting.Report, error) {
+	repository := pipelines.NewRepository(p.settings)
+	all, err := repository.FindAllDefinitions()
 
 	if err != nil {
-		logrus.Fatal(err)
+		return nil, err
+	}
+
+	pp, err := repository.FilterDefinitions(all, p.Filter)
+
+	if err != nil {
+		return nil, err
 	}
 
 	checker := engine.New(p.settings, p.Filter)
-	r := checker.Execute(pp)
+	r, err := checker.Execute(pp, all)
 
 	if len(p.Filter.Status) > 0 {
 		r = r.FilterByLevel(p.Filter.Status)
 	}
 
-	return r
+	// Report is built, even if some data sources are in error
+	return r, err
 }
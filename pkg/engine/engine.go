@@ -1,9 +1,9 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
 
-	"github.com/dustin/go-humanize"
 	"github.com/sirupsen/logrus"
 
 	"github.com/datatok/tintin/pkg/executions"
@@ -14,42 +14,118 @@ import (
 	"github.com/datatok/tintin/pkg/utils/constant"
 	"github.com/datatok/tintin/pkg/utils/links"
 
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
 	MetricsLogServerFrontURL = "djobi_es_search"
 	SparkHistoryFrontURL     = "spark_history"
 	YARNHistoryFrontURL      = "yarn_history"
+
+	// Format of schedule filter, like "31/12/2021"
+	ScheduleDateFormat = "02/01/2006"
 )
 
 type Checker struct {
-	settings *cli.EnvSettings
-	jobs     *executions.JobsStore
-	stages   *executions.StagesStore
-	filter   utils.Filter
-	urls     links.Repository
+	settings   *cli.EnvSettings
+	executions executions.Backend
+	filter     utils.Filter
+	urls       links.Repository
+
+	// Business-day calendars, for pipelines schedule
+	calendars pipelines.Calendars
+
+	// Stage kind aliases & renderers
+	kinds StageKinds
+
+	// Rule sets deriving work status from stage phases
+	rules StatusRules
+
+	// All pipelines, not only the report ones, to find the ones sharing a short pipeline name
+	definitions []pipelines.Definition
+
+	// Job executions could not be (fully) fetched
+	jobsErr error
+
+	// Data source errors met while checking works
+	errors []error
 }
 
 func New(settings *cli.EnvSettings, filter utils.Filter) *Checker {
+	return NewWithBackend(settings, filter, executions.NewBackend(settings, filter.Schedule))
+}
+
+/**
+ * Checker of executions from the backend (elasticsearch, files or a fake one).
+ */
+func NewWithBackend(settings *cli.EnvSettings, filter utils.Filter, backend executions.Backend) *Checker {
+	calendars, err := pipelines.LoadCalendars(settings.CalendarsPath)
+
+	if err != nil {
+		logrus.Errorf("unable to load calendars: %s", err)
+	}
+
+	kinds, err := LoadStageKinds(settings.StageKindsPath)
+
+	if err != nil {
+		logrus.Errorf("unable to load stage kinds: %s", err)
+	}
+
+	rules, err := LoadStatusRules(settings.StatusRulesPath)
+
+	if err != nil {
+		logrus.Errorf("unable to load status rules: %s", err)
+	}
+
 	return &Checker{
-		settings: settings,
-		filter:   filter,
-		jobs:     executions.NewJobsStore(settings, filter.Schedule),
-		stages:   executions.NewStagesStore(settings, filter.Schedule),
-		urls:     links.Load(settings.FrontURLPath),
+		settings:   settings,
+		filter:     filter,
+		executions: backend,
+		urls:       links.Load(settings.FrontURLPath),
+		calendars:  calendars,
+		kinds:      kinds,
+		rules:      rules,
 	}
 }
 
 /**
- * Generate the full report.
+ * Generate the full report of pipelines, among all pipelines (all of them if nil).
+ * Data source errors are kept on the report, and works they affect are DATA_UNAVAILABLE:
+ * the report is returned with the first of them.
  */
-func (c *Checker) Execute(pipelines []pipelines.Definition) *reporting.Report {
+func (c *Checker) Execute(pipelines []pipelines.Definition, all []pipelines.Definition) (*reporting.Report, error) {
 	rp := reporting.NewReport(c.filter)
 
-	c.jobs.FetchJobsExecutions()
+	if all == nil {
+		all = pipelines
+	}
+
+	c.errors = nil
+	c.definitions = all
+	c.jobsErr = c.executions.FetchJobsExecutions()
+
+	// Truncated executions: listed on the report, works without execution are unavailable, the report is built
+	if errors.Is(c.jobsErr, executions.ErrTruncated) {
+		rp.AddError(c.jobsErr)
+	} else if c.jobsErr != nil {
+		c.errors = append(c.errors, c.jobsErr)
+	}
+
+	if err := c.executions.FetchStagesOfJobs(c.matchedJobExecutionUIDs(pipelines)); err != nil {
+		c.errors = append(c.errors, err)
+	}
 
 	for _, pipeline := range pipelines {
+		if !pipeline.IsValid() {
+			logrus.Warnf("skipping invalid pipeline %s", pipeline.FullName)
+
+			rp.InvalidPipelines = append(rp.InvalidPipelines, pipeline)
+
+			continue
+		}
+
 		rp.Pipelines = append(rp.Pipelines, c.Check(pipeline))
 	}
 
@@ -59,13 +135,52 @@ func (c *Checker) Execute(pipelines []pipelines.Definition) *reporting.Report {
 			Date:     c.filter.Schedule,
 			Status:   c.filter.Status,
 			Team:     c.filter.Team,
-			Pipeline: c.filter.Pipelines,
+			Pipeline: strings.Join(c.filter.Pipelines, ","),
+			Owner:    c.filter.Owner,
 		},
 	}
 
 	rp.CalculateCounters()
 
-	return rp
+	for _, err := range c.errors {
+		rp.AddError(err)
+	}
+
+	if len(c.errors) > 0 {
+		return rp, c.errors[0]
+	}
+
+	return rp, nil
+}
+
+/**
+ * UIDs of job executions matched by works of valid pipelines: the ones whose stages are checked.
+ */
+func (c *Checker) matchedJobExecutionUIDs(definitions []pipelines.Definition) []string {
+	var ret []string
+
+	matched := make(map[string]bool)
+
+	for _, pipeline := range definitions {
+		if !pipeline.IsValid() {
+			continue
+		}
+
+		for _, job := range pipeline.Jobs {
+			for _, contextDefinition := range job.Contexts {
+				for _, jobExecution := range c.executions.MatchJobExecutions(pipeline, job.ContextWorkName(contextDefinition)).Executions {
+					if !matched[jobExecution.UID] {
+						matched[jobExecution.UID] = true
+						ret = append(ret, jobExecution.UID)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Strings(ret)
+
+	return ret
 }
 
 /**
@@ -85,6 +200,10 @@ func (c *Checker) Check(pipeline pipelines.Definition) reporting.Pipeline {
 		for _, contextDefinition := range job.Contexts {
 			res := c.checkWork(pipeline, job, contextDefinition)
 
+			if res.Status == constant.Skipped {
+				counters.Skipped++
+			}
+
 			reportJob.Works = append(reportJob.Works, res)
 		}
 
@@ -103,40 +222,113 @@ func (c *Checker) Check(pipeline pipelines.Definition) reporting.Pipeline {
 
 /**
  * Check piece of work : job context, on all stages.
+ * Every attempt (djobi retry) is checked, the latest one decides.
  */
-//nolint:ineffassign
 func (c *Checker) checkWork(pipeline pipelines.Definition, job pipelines.JobDefinition, contextDefinition pipelines.JobContextDefinition) reporting.Work {
-	var (
-		stageExecutions []executions.StageHit
-	)
-
 	ret := reporting.Work{
 		Context: contextDefinition,
-		Name:    job.Name,
+		Name:    job.ContextWorkName(contextDefinition),
 		Stages:  make(map[string]reporting.WorkStageDetails),
 	}
 
-	displayMessage := "No execution log found!"
+	logrus.Debugf("checking pipeline %s job %s", pipeline.Name, ret.Name)
+
+	rules, err := c.rules.RuleSet(pipeline)
 
-	if contextDefinition.Type == pipelines.ContextTypeSet {
-		ret.Name += "_" + contextDefinition.Name
+	if err != nil {
+		ret.Warnings = append(ret.Warnings, err.Error())
 	}
 
-	logrus.Debugf("checking pipeline %s job %s", pipeline.Name, ret.Name)
+	// Get djobi-jobs executions, for this pipeline work
+	match := c.executions.MatchJobExecutions(pipeline, ret.Name)
+
+	if match.IsAmbiguous() {
+		ret.Warnings = append(ret.Warnings, fmt.Sprintf("Executions of several pipelines named %s (%s), latest is checked: set meta.id", pipeline.FullName, strings.Join(match.Conflicts, ", ")))
+	}
+
+	if match.By == executions.MatchBySuffix {
+		if others := suffixConflicts(pipeline, match, c.definitions); len(others) > 0 {
+			ret.Warnings = append(ret.Warnings, fmt.Sprintf("Executions of pipeline named %s also match %s, latest is checked: set meta.id", strings.Join(match.PipelineNames(), ", "), strings.Join(others, ", ")))
+		}
+	}
+
+	// No execution, and none was expected: nothing to check
+	if len(match.Executions) == 0 {
+		if expected, reason := c.isRunExpected(pipeline, job); !expected {
+			fillStatus(&ret, false, constant.Skipped, reason)
+
+			return ret
+		}
+
+		// Not fetched, the execution may exist
+		if c.jobsErr != nil {
+			fillStatus(&ret, false, constant.DataUnavailable, "Job executions are unavailable, see data source errors")
+
+			return ret
+		}
+
+		return c.checkExecution(ret, job, rules, nil)
+	}
+
+	var attempts []reporting.Attempt
+
+	for i := range match.Executions {
+		jobExecution := &match.Executions[i]
+
+		ret = c.checkExecution(ret, job, rules, jobExecution)
+
+		attempts = append(attempts, reporting.Attempt{
+			Number:              i + 1,
+			UID:                 jobExecution.UID,
+			Status:              ret.Status,
+			Success:             ret.Success,
+			Details:             ret.Details,
+			Timeline:            ret.Timeline,
+			LinkToJobLogs:       ret.LinkToJobLogs,
+			LinkToJobStagesLogs: ret.LinkToJobStagesLogs,
+		})
+	}
+
+	ret.Attempts = attempts
 
-	// Get djobi-jobs execution, for this pipeline execution
-	jobExecution := c.jobs.FindJobExecution(pipeline, ret.Name)
+	return ret
+}
+
+/**
+ * Check an execution of the work (nil if not found), on all stages.
+ */
+//nolint:ineffassign
+func (c *Checker) checkExecution(ret reporting.Work, job pipelines.JobDefinition, rules StatusRuleSet, jobExecution *executions.JobExecution) reporting.Work {
+	var (
+		stageExecutions []executions.StageHit
+	)
+
+	ret.Stages = make(map[string]reporting.WorkStageDetails)
+
+	displayMessage := "No execution log found!"
 
 	// If we found job execution -> find jobs stages executions
 	if jobExecution != nil {
 		ret.Timeline = jobExecution.Timeline
 
-		ret.LinkToJobLogs = c.urls.Generate(MetricsLogServerFrontURL, map[string]string{"index": c.jobs.StoreName, "query": "_id:" + jobExecution.UID})
-		ret.LinkToJobStagesLogs = c.urls.Generate(MetricsLogServerFrontURL, map[string]string{"index": c.stages.StoreName, "query": "job.uid:" + jobExecution.UID})
+		jobLogs, stagesLogs := c.executions.JobLogs(jobExecution.UID), c.executions.StagesLogs(jobExecution.UID)
+
+		ret.LinkToJobLogs = c.urls.Generate(MetricsLogServerFrontURL, map[string]string{"index": jobLogs.Index, "query": jobLogs.Query})
+		ret.LinkToJobStagesLogs = c.urls.Generate(MetricsLogServerFrontURL, map[string]string{"index": stagesLogs.Index, "query": stagesLogs.Query})
 		ret.LinkToSparkHistory = c.urls.Generate(SparkHistoryFrontURL, map[string]string{"app_id": jobExecution.Executor.Spark.Spark.Application.ID})
 		ret.LinkToYARNHistory = c.urls.Generate(YARNHistoryFrontURL, map[string]string{"app_id": jobExecution.Executor.Spark.Spark.Application.ID})
 
-		stageExecutions = c.stages.FetchStagesExecutions(jobExecution.UID)
+		var err error
+
+		stageExecutions, err = c.executions.FetchStagesExecutions(jobExecution.UID)
+
+		if err != nil {
+			c.errors = append(c.errors, err)
+
+			fillStatus(&ret, false, constant.DataUnavailable, "Stage executions are unavailable, see data source errors")
+
+			return ret
+		}
 
 		if len(stageExecutions) == 0 {
 			displayMessage = "Stage execution log is not found!"
@@ -152,28 +344,32 @@ func (c *Checker) checkWork(pipeline pipelines.Definition, job pipelines.JobDefi
 
 			// If pre-check in error
 			if stageExecution != nil && stageExecution.PreCheck.Status == constant.DoneError {
-				ret.Stages[fixStageName(stageDefinition.Kind)] = reporting.WorkStageDetails{
-					Resume: reporting.Status{
-						Status:  constant.DoneError,
-						Details: stageExecution.PreCheck.Meta.Reason,
-					},
+				details := c.stageDetails(stageDefinition, *stageExecution, ret.LinkToJobStagesLogs)
+
+				details.Resume = reporting.Status{
+					Status:  constant.DoneError,
+					Details: stageExecution.PreCheck.Meta.Reason,
 				}
+
+				ret.Stages[c.kinds.Resolve(stageDefinition.Kind)] = details
 			}
 
-			// If output stage OR stage has failed
-			if stageDefinition.IsOutputStage() || (stageExecution != nil && stageExecution.Status == constant.DoneError) {
+			// If output stage OR stage has failed (or all stages, by rules)
+			if rules.checksStage(stageDefinition, stageExecution) {
 				if stageExecution == nil {
-					ret.Stages[fixStageName(stageDefinition.Kind)] = reporting.WorkStageDetails{
+					notFound := reporting.Status{Status: constant.No}
+
+					ret.Stages[c.kinds.Resolve(stageDefinition.Kind)] = reporting.WorkStageDetails{
 						Resume: reporting.Status{
 							Status:  constant.No,
 							Details: "Stage execution is not found!",
 						},
+						PreCheck:  notFound,
+						Run:       notFound,
+						PostCheck: notFound,
 					}
 				} else {
-					ret.Stages[fixStageName(stageExecution.Kind)] = reporting.WorkStageDetails{
-						Resume: c.stagePhaseToReportStatus(*stageExecution),
-						Log:    *stageExecution,
-					}
+					ret.Stages[c.kinds.Resolve(stageExecution.Kind)] = c.stageDetails(stageDefinition, *stageExecution, ret.LinkToJobStagesLogs)
 				}
 			}
 		}
@@ -187,10 +383,7 @@ func (c *Checker) checkWork(pipeline pipelines.Definition, job pipelines.JobDefi
 
 				// If output stage OR stage has failed
 				if stageExecution != nil {
-					ret.Stages[fixStageName(stageExecution.Kind)] = reporting.WorkStageDetails{
-						Resume: c.stagePhaseToReportStatus(*stageExecution),
-						Log:    *stageExecution,
-					}
+					ret.Stages[c.kinds.Resolve(stageExecution.Kind)] = c.stageDetails(stageDefinition, *stageExecution, ret.LinkToJobStagesLogs)
 				}
 			}
 		}
@@ -202,26 +395,16 @@ func (c *Checker) checkWork(pipeline pipelines.Definition, job pipelines.JobDefi
 	)
 
 	if len(ret.Stages) == 0 {
-		logrus.Warnf("stages not found for %s / %s", pipeline.FullName, job.Name)
+		logrus.Warnf("stages not found for %s", ret.Name)
 	} else {
-		stageExecutionSuccess = true
-		outStatus = constant.DoneOk
-
-		for _, s := range ret.Stages {
-			if s.Log.Status == constant.DoneOk {
-				if s.Log.PostCheck.Status == constant.DoneError {
-					stageExecutionSuccess = false
-					outStatus = constant.DoneError
-				} else if s.Log.PostCheck.Status == constant.Todo ||
-					s.Log.PostCheck.Status == constant.DoneUnknown ||
-					s.Log.PostCheck.Status == constant.InProgress {
-					stageExecutionSuccess = false
-					outStatus = constant.DoneUnknown
-				}
-			} else {
-				stageExecutionSuccess = false
-				outStatus = constant.DoneError
-			}
+		var message string
+
+		// Resume status is post-check status, after expectations evaluation
+		outStatus, message = rules.Evaluate(ret.Stages)
+		stageExecutionSuccess = outStatus == constant.DoneOk
+
+		if len(message) > 0 {
+			displayMessage = message
 		}
 	}
 
@@ -230,6 +413,35 @@ func (c *Checker) checkWork(pipeline pipelines.Definition, job pipelines.JobDefi
 	return ret
 }
 
+/**
+ * Is a run of the job expected on schedule day, else why.
+ */
+func (c *Checker) isRunExpected(pipeline pipelines.Definition, job pipelines.JobDefinition) (bool, string) {
+	schedule := pipeline.JobSchedule(job)
+
+	if schedule == nil {
+		return true, ""
+	}
+
+	day, err := time.Parse(ScheduleDateFormat, c.filter.Schedule)
+
+	if err != nil {
+		logrus.Warnf("unable to parse schedule date %q: %s", c.filter.Schedule, err)
+
+		return true, ""
+	}
+
+	expected, err := schedule.Expects(day, c.calendars)
+
+	if err != nil {
+		logrus.Warnf("pipeline %s job %s: %s", pipeline.FullName, job.Name, err)
+
+		return true, ""
+	}
+
+	return expected, fmt.Sprintf("No run expected on %s (%s)", c.filter.Schedule, schedule)
+}
+
 /**
  * Search stage execution log
  */
@@ -247,6 +459,30 @@ func searchStageExecution(stageDefinition pipelines.StageDefinition, hits []exec
 	return nil
 }
 
+/**
+ * Other pipelines matching the short pipeline names of executions.
+ */
+func suffixConflicts(pipeline pipelines.Definition, match executions.JobExecutionMatch, definitions []pipelines.Definition) []string {
+	var ret []string
+
+	for _, definition := range definitions {
+		if definition.FullName == pipeline.FullName {
+			continue
+		}
+
+		for _, name := range match.PipelineNames() {
+			if executions.IsPipelineSuffix(name, definition.FullName) {
+				ret = append(ret, definition.FullName)
+				break
+			}
+		}
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
 func fillStatus(work *reporting.Work, success bool, status string, reason string) {
 	work.Success = success
 	work.Status = status
@@ -254,57 +490,58 @@ func fillStatus(work *reporting.Work, success bool, status string, reason string
 }
 
 /**
- * If old stage definition
+ * Stage details: resume (post-check, after expectations), and pre-check / run / post-check phases.
  */
-func fixStageName(stageKind string) string {
-	if strings.Contains(stageKind, ".") {
-		return stageKind
+func (c *Checker) stageDetails(stageDefinition pipelines.StageDefinition, stage executions.StageHit, stagesLink string) reporting.WorkStageDetails {
+	postCheck := c.stagePhaseToReportStatus(stageDefinition, stage)
+
+	run := reporting.Status{
+		Status: stage.Status,
+		Link:   stagesLink,
 	}
 
-	switch stageKind {
-	case "elasticsearch":
-		return "org.elasticsearch.output"
+	if stage.Error != nil {
+		run.Details = stage.Error.Message
 	}
 
-	return stageKind
+	return reporting.WorkStageDetails{
+		Log:       stage,
+		Resume:    postCheck,
+		PreCheck:  checkPhaseStatus(stage.PreCheck),
+		Run:       run,
+		PostCheck: postCheck,
+	}
 }
 
-func (c *Checker) stagePhaseToReportStatus(stage executions.StageHit) reporting.Status {
+/**
+ * Status of a check phase, as logged by djobi (empty if not logged).
+ */
+func checkPhaseStatus(phase executions.StagePhase) reporting.Status {
+	details := phase.Meta.Reason
+
+	if len(details) == 0 {
+		details = phase.Meta.Display
+	}
+
+	return reporting.Status{
+		Status:  phase.Status,
+		Details: details,
+		Link:    phase.Link,
+	}
+}
 
+/**
+ * Post-check status of a stage: details & link of a done post-check come from the renderer of its kind.
+ */
+func (c *Checker) stagePhaseToReportStatus(stageDefinition pipelines.StageDefinition, stage executions.StageHit) reporting.Status {
 	phase := stage.PostCheck
+	renderer := c.kinds.Renderer(stage.Kind)
 	details := phase.Meta.Reason
 	link := phase.Link
 
 	switch phase.Status {
-	case constant.DoneError:
-		if phase.Meta.Value == 0 && strings.Contains(stage.Kind, "elasticsearch") {
-			details = fmt.Sprintf("no document in %s", phase.Meta.Index)
-			link = c.urls.Generate("es_conso", map[string]string{"index": phase.Meta.Index, "query": phase.Meta.Query})
-		} else if strings.Contains(stage.Kind, "scp") {
-			details = SCPBuildDisplay(stage)
-		} else if len(phase.Meta.Reason) > 0 {
-			details = phase.Meta.Reason
-		} else {
-			details = phase.Meta.Display
-		}
-
-	case constant.DoneOk:
-		if strings.Contains(stage.Kind, "elasticsearch") {
-			details = fmt.Sprintf("%s documents in %s", humanize.FormatInteger("# ###,", phase.Meta.Value), phase.Meta.Index)
-			link = c.urls.Generate("es_conso", map[string]string{"index": phase.Meta.Index, "query": phase.Meta.Query})
-		} else if strings.Contains(stage.Kind, "scp") {
-			details = SCPBuildDisplay(stage) // fmt.Sprintf("File of %s", phase.Meta.Display)
-		} else if len(phase.Meta.Reason) > 0 {
-			details = phase.Meta.Reason
-		} else if len(phase.Meta.Unit) > 0 {
-			if phase.Meta.Unit == "byte" {
-				details = ByteCountSI(int64(phase.Meta.Value))
-			} else {
-				details = fmt.Sprintf("%s %s", humanize.FormatInteger("# ###,", phase.Meta.Value), phase.Meta.Unit)
-			}
-		} else {
-			details = phase.Meta.Display
-		}
+	case constant.DoneError, constant.DoneOk:
+		details, link = renderer.Render(stage, c.urls)
 
 	case constant.Todo, constant.No, constant.DoneUnknown:
 		if stage.PreCheck.Status == constant.DoneError {
@@ -324,11 +561,11 @@ func (c *Checker) stagePhaseToReportStatus(stage executions.StageHit) reporting.
 		details += "\nRun error: \"" + stage.Error.Message + "\""
 	}
 
-	return reporting.Status{
+	return evaluateExpectations(stageDefinition, stage, renderer, reporting.Status{
 		Status:  phase.Status,
 		Details: details,
 		Link:    link,
-	}
+	})
 }
 
 func ByteCountSI(b int64) string {
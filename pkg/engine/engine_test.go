@@ -0,0 +1,346 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datatok/tintin/pkg/executions"
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/reporting"
+	"github.com/datatok/tintin/pkg/utils"
+	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/constant"
+)
+
+func testSettings() *cli.EnvSettings {
+	return &cli.EnvSettings{
+		MetricsLogAPIURL:        "file://testdata/executions",
+		MetricsLogJobsIndex:     "djobi-jobs",
+		MetricsLogStagesIndex:   "djobi-stages",
+		MetricsLogScheduleField: "meta.title.keyword",
+		MetricsLogJobUIDField:   "job.uid",
+	}
+}
+
+func testContexts(names ...string) map[string]pipelines.JobContextDefinition {
+	ret := make(map[string]pipelines.JobContextDefinition)
+
+	for _, name := range names {
+		ret[name] = pipelines.JobContextDefinition{Name: name, Type: pipelines.ContextTypeSet}
+	}
+
+	return ret
+}
+
+/**
+ * Works of the report, by name.
+ */
+func reportWorks(report *reporting.Report) map[string]reporting.Work {
+	ret := make(map[string]reporting.Work)
+
+	for _, pipeline := range report.Pipelines {
+		for _, job := range pipeline.Jobs {
+			for _, work := range job.Works {
+				ret[pipeline.Definition.FullName+"/"+work.Name] = work
+			}
+		}
+	}
+
+	return ret
+}
+
+func TestChecker_Execute(t *testing.T) {
+	min := int64(100)
+	output := pipelines.StageDefinition{Stage: "output", Kind: "org.elasticsearch.output"}
+	outputWithMin := pipelines.StageDefinition{Stage: "output", Kind: "org.elasticsearch.output", MinValue: &min}
+
+	definitions := []pipelines.Definition{
+		{
+			FullName: "team_a/conso",
+			Jobs: map[string]pipelines.JobDefinition{
+				"conso": {
+					Name:     "conso",
+					Contexts: testContexts("fr", "de", "it", "uk", "pl", "es"),
+					Stages:   map[string]pipelines.StageDefinition{"output": output},
+				},
+				"export": {
+					Name:     "export",
+					Contexts: map[string]pipelines.JobContextDefinition{pipelines.DefaultContextName: {Name: pipelines.DefaultContextName, Type: pipelines.ContextTypeDefault}},
+					Stages:   map[string]pipelines.StageDefinition{"output": outputWithMin},
+				},
+			},
+		},
+		{
+			FullName: "team_a/weekly",
+			Schedule: &pipelines.ScheduleDefinition{Weekdays: []string{"mon"}},
+			Jobs: map[string]pipelines.JobDefinition{
+				"weekly": {
+					Name:     "weekly",
+					Contexts: testContexts("fr"),
+					Stages:   map[string]pipelines.StageDefinition{"output": output},
+				},
+			},
+		},
+	}
+
+	settings := testSettings()
+	filter := utils.Filter{Schedule: "21/10/2021"}
+
+	report, err := NewWithBackend(settings, filter, executions.NewBackend(settings, filter.Schedule)).Execute(definitions, nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+
+	works := reportWorks(report)
+
+	tests := []struct {
+		work    string
+		status  string
+		success bool
+		details string
+	}{
+		{"team_a/conso/conso_fr", constant.DoneOk, true, ""},
+		{"team_a/conso/conso_de", constant.DoneError, false, ""},
+		{"team_a/conso/conso_it", constant.DoneError, false, "Stage execution log is not found!"},
+		{"team_a/conso/conso_uk", constant.DoneError, false, "No execution log found!"},
+		{"team_a/conso/conso_pl", constant.DoneOk, true, ""},
+		{"team_a/conso/conso_es", constant.DoneOk, true, ""},
+		{"team_a/conso/export", constant.DoneError, false, ""},
+		{"team_a/weekly/weekly_fr", constant.Skipped, false, "No run expected on 21/10/2021 (mon)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.work, func(t *testing.T) {
+			work, ok := works[tt.work]
+
+			if assert.True(t, ok, "work is reported") {
+				assert.Equal(t, tt.status, work.Status)
+				assert.Equal(t, tt.success, work.Success)
+				assert.Equal(t, tt.details, work.Details)
+			}
+		})
+	}
+
+	assert.Equal(t, "1 200 documents in conso-fr", works["team_a/conso/conso_fr"].Stages["org.elasticsearch.output"].Resume.Details)
+	assert.Equal(t, 600000, works["team_a/conso/conso_fr"].Timeline.Duration)
+	assert.Equal(t, "no document in conso-de", works["team_a/conso/conso_de"].Stages["org.elasticsearch.output"].Resume.Details)
+	assert.Equal(t, "12 documents, expected at least 100 documents", works["team_a/conso/export"].Stages["org.elasticsearch.output"].Resume.Details)
+
+	assert.Equal(t, "12 documents in conso-pl", works["team_a/conso/conso_pl"].Stages["org.elasticsearch.output"].Resume.Details, "latest execution is checked")
+	assert.Equal(t, []string{"Executions of several pipelines named team_a/conso (p1, p2), latest is checked: set meta.id"}, works["team_a/conso/conso_pl"].Warnings)
+	assert.Empty(t, works["team_a/conso/conso_fr"].Warnings)
+
+	deOutput := works["team_a/conso/conso_de"].Stages["org.elasticsearch.output"]
+
+	assert.Equal(t, reporting.Status{}, deOutput.PreCheck, "pre-check not logged")
+	assert.Equal(t, constant.DoneOk, deOutput.Run.Status)
+	assert.Equal(t, constant.DoneError, deOutput.PostCheck.Status)
+	assert.Equal(t, "no document in conso-de", deOutput.PostCheck.Details)
+
+	itOutput := works["team_a/conso/conso_it"].Stages["org.elasticsearch.output"]
+
+	assert.Equal(t, constant.No, itOutput.PreCheck.Status)
+	assert.Equal(t, constant.No, itOutput.Run.Status)
+	assert.Equal(t, constant.No, itOutput.PostCheck.Status)
+
+	retried := works["team_a/conso/conso_es"]
+
+	if assert.Len(t, retried.Attempts, 3) {
+		assert.Equal(t, "j8", retried.Attempts[0].UID)
+		assert.Equal(t, constant.DoneError, retried.Attempts[0].Status)
+		assert.Equal(t, "j10", retried.Attempts[1].UID)
+		assert.Equal(t, "j9", retried.Attempts[2].UID)
+		assert.Equal(t, 3, retried.Attempts[2].Number)
+	}
+
+	assert.Equal(t, "failed twice, succeeded on 3rd attempt", retried.RetrySummary())
+	assert.Equal(t, constant.DoneError, retried.Attempts[0].Status, "first attempt run failed")
+	assert.Equal(t, "40 documents in conso-es", retried.Stages["org.elasticsearch.output"].Resume.Details, "latest attempt decides")
+	assert.Len(t, works["team_a/conso/conso_fr"].Attempts, 1)
+	assert.Empty(t, works["team_a/conso/conso_uk"].Attempts)
+
+	assert.Equal(t, 3, report.Counters.Success)
+	assert.Equal(t, 4, report.Counters.Errors)
+	assert.Equal(t, 1, report.Counters.Skipped)
+}
+
+// stagesRecorder records job executions whose stages are fetched.
+type stagesRecorder struct {
+	executions.Backend
+	uids []string
+}
+
+func (r *stagesRecorder) FetchStagesOfJobs(jobExecutionUIDs []string) error {
+	r.uids = jobExecutionUIDs
+
+	return r.Backend.FetchStagesOfJobs(jobExecutionUIDs)
+}
+
+func TestChecker_Execute_FetchesStagesOfMatchedJobs(t *testing.T) {
+	settings := testSettings()
+	filter := utils.Filter{Schedule: "21/10/2021"}
+	backend := &stagesRecorder{Backend: executions.NewBackend(settings, filter.Schedule)}
+
+	definitions := []pipelines.Definition{
+		{
+			FullName: "team_a/conso",
+			Jobs: map[string]pipelines.JobDefinition{
+				"export": {
+					Name:     "export",
+					Contexts: map[string]pipelines.JobContextDefinition{pipelines.DefaultContextName: {Name: pipelines.DefaultContextName, Type: pipelines.ContextTypeDefault}},
+					Stages:   map[string]pipelines.StageDefinition{"output": {Stage: "output", Kind: "org.elasticsearch.output"}},
+				},
+			},
+		},
+		{
+			FullName: "team_a/invalid",
+			Meta:     pipelines.MetaDefinition{ID: "p1"},
+			Errors:   []pipelines.ValidationError{{File: "pipeline.yaml", Message: "invalid"}},
+			Jobs: map[string]pipelines.JobDefinition{
+				"conso": {Name: "conso", Contexts: testContexts("fr")},
+			},
+		},
+	}
+
+	_, err := NewWithBackend(settings, filter, backend).Execute(definitions, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"j4"}, backend.uids, "stages of other pipelines & invalid ones are not fetched")
+}
+
+// truncatedBackend fetches some job executions only.
+type truncatedBackend struct {
+	executions.Backend
+}
+
+func (b truncatedBackend) FetchJobsExecutions() error {
+	if err := b.Backend.FetchJobsExecutions(); err != nil {
+		return err
+	}
+
+	return &executions.DataSourceError{Source: "djobi-jobs", Err: fmt.Errorf("%w: fetched 10 of 12", executions.ErrTruncated)}
+}
+
+func TestChecker_Execute_Truncated(t *testing.T) {
+	settings := testSettings()
+	filter := utils.Filter{Schedule: "21/10/2021"}
+
+	definitions := []pipelines.Definition{
+		{
+			FullName: "team_a/conso",
+			Jobs: map[string]pipelines.JobDefinition{
+				"conso": {
+					Name:     "conso",
+					Contexts: testContexts("fr", "uk"),
+					Stages:   map[string]pipelines.StageDefinition{"output": {Stage: "output", Kind: "org.elasticsearch.output"}},
+				},
+			},
+		},
+	}
+
+	report, err := NewWithBackend(settings, filter, truncatedBackend{executions.NewBackend(settings, filter.Schedule)}).Execute(definitions, nil)
+
+	require.NoError(t, err, "a truncated report is still built")
+	assert.Equal(t, []reporting.ReportError{{Source: "djobi-jobs", Message: "job executions are truncated: fetched 10 of 12"}}, report.Errors)
+
+	works := reportWorks(report)
+
+	assert.Equal(t, constant.DoneOk, works["team_a/conso/conso_fr"].Status)
+	assert.Equal(t, constant.DataUnavailable, works["team_a/conso/conso_uk"].Status, "a missing execution may not have been fetched")
+}
+
+func TestChecker_Execute_DataUnavailable(t *testing.T) {
+	checker := &Checker{
+		filter: utils.Filter{Schedule: "21/10/2021"},
+		executions: &executions.ElasticsearchBackend{
+			Jobs:   &executions.JobsStore{StoreName: "djobi-jobs"},
+			Stages: &executions.StagesStore{StoreName: "djobi-stages"},
+		},
+	}
+
+	definition := pipelines.Definition{
+		FullName: "team_a/conso",
+		Jobs: map[string]pipelines.JobDefinition{
+			"conso": {
+				Name:     "conso",
+				Contexts: map[string]pipelines.JobContextDefinition{pipelines.DefaultContextName: {Name: pipelines.DefaultContextName, Type: pipelines.ContextTypeDefault}},
+			},
+		},
+	}
+
+	report, err := checker.Execute([]pipelines.Definition{definition}, nil)
+
+	assert.ErrorIs(t, err, executions.ErrUnavailable)
+
+	if assert.NotNil(t, report) && assert.Len(t, report.Pipelines, 1) {
+		work := report.Pipelines[0].Jobs[0].Works[0]
+
+		assert.Equal(t, constant.DataUnavailable, work.Status)
+		assert.False(t, work.Success)
+		assert.Equal(t, 1, report.Counters.Unavailable)
+		assert.Equal(t, 0, report.Counters.Executions)
+		assert.Contains(t, report.Title, "1 unavailable")
+	}
+
+	assert.Equal(t, []reporting.ReportError{
+		{Source: "djobi-jobs", Message: "elasticsearch is not available"},
+		{Source: "djobi-stages", Message: "elasticsearch is not available"},
+	}, report.Errors)
+
+	assert.Len(t, report.FilterByLevel([]string{"unavailable"}).Pipelines, 1)
+}
+
+func TestChecker_Execute_SuffixConflictsOfAllPipelines(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "djobi-jobs.ndjson"), []byte(`{"uid": "j1", "id": "archivr", "meta": {"title": "21/10/2021"}, "pipeline": {"name": "archivr"}}`+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "djobi-stages.ndjson"), []byte("\n"), 0644))
+
+	settings := testSettings()
+	settings.MetricsLogAPIURL = "file://" + dir
+	filter := utils.Filter{Schedule: "21/10/2021", Team: "team_a"}
+
+	definition := func(fullName string) pipelines.Definition {
+		return pipelines.Definition{
+			FullName: fullName,
+			Jobs: map[string]pipelines.JobDefinition{
+				"archivr": {
+					Name:     "archivr",
+					Contexts: map[string]pipelines.JobContextDefinition{pipelines.DefaultContextName: {Name: pipelines.DefaultContextName, Type: pipelines.ContextTypeDefault}},
+				},
+			},
+		}
+	}
+
+	all := []pipelines.Definition{definition("team_a/archivr"), definition("team_b/archivr")}
+
+	report, err := NewWithBackend(settings, filter, executions.NewBackend(settings, filter.Schedule)).Execute(all[:1], all)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Executions of pipeline named archivr also match team_b/archivr, latest is checked: set meta.id"},
+		reportWorks(report)["team_a/archivr/archivr"].Warnings, "pipelines filtered out of the report also match")
+}
+
+func TestSuffixConflicts(t *testing.T) {
+	legacy := executions.JobExecution{UID: "legacy"}
+	legacy.Pipeline = &struct {
+		UID, Name  string
+		Definition pipelines.Definition
+	}{Name: "archivr"}
+
+	match := executions.JobExecutionMatch{Executions: []executions.JobExecution{legacy}, By: executions.MatchBySuffix}
+
+	definitions := []pipelines.Definition{
+		{FullName: "team_a/archivr"},
+		{FullName: "team_b/archivr"},
+		{FullName: "team_b/old_archivr"},
+	}
+
+	assert.Equal(t, []string{"team_b/archivr"}, suffixConflicts(definitions[0], match, definitions))
+	assert.Empty(t, suffixConflicts(definitions[0], match, definitions[:1]))
+}
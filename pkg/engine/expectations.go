@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/datatok/tintin/pkg/executions"
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/reporting"
+	"github.com/datatok/tintin/pkg/utils/constant"
+)
+
+/**
+ * Evaluate stage definition expectations (min_value, max_value, allow_empty) against
+ * post-check value: status is downgraded or upgraded, with the reason as details.
+ * The post-check of a failed run is not upgraded: the value does not fix the run error.
+ */
+func evaluateExpectations(stageDefinition pipelines.StageDefinition, stage executions.StageHit, renderer StageRenderer, status reporting.Status) reporting.Status {
+	phase := stage.PostCheck
+
+	if !stageDefinition.HasExpectations() || (phase.Status != constant.DoneOk && phase.Status != constant.DoneError) {
+		return status
+	}
+
+	value := int64(phase.Meta.Value)
+	unit := expectationUnit(stageDefinition, stage, renderer)
+	runFailed := stage.Status == constant.DoneError
+
+	switch {
+	case value == 0 && stageDefinition.AllowEmpty:
+		if runFailed {
+			return status
+		}
+
+		status.Status = constant.DoneOk
+		status.Details = fmt.Sprintf("no %s, allowed empty", unit)
+
+	case stageDefinition.MinValue != nil && value < *stageDefinition.MinValue:
+		status.Status = constant.DoneError
+		status.Details = fmt.Sprintf("%s, expected at least %s", formatValue(value, unit), formatValue(*stageDefinition.MinValue, unit))
+
+	case stageDefinition.MaxValue != nil && value > *stageDefinition.MaxValue:
+		status.Status = constant.DoneError
+		status.Details = fmt.Sprintf("%s, expected at most %s", formatValue(value, unit), formatValue(*stageDefinition.MaxValue, unit))
+
+	case stageDefinition.MinValue != nil || stageDefinition.MaxValue != nil:
+		if runFailed {
+			return status
+		}
+
+		if status.Status != constant.DoneOk {
+			status.Details = fmt.Sprintf("%s, as expected", formatValue(value, unit))
+		}
+
+		status.Status = constant.DoneOk
+	}
+
+	return status
+}
+
+/**
+ * Unit from definition, else from execution log, else from stage kind renderer.
+ */
+func expectationUnit(stageDefinition pipelines.StageDefinition, stage executions.StageHit, renderer StageRenderer) string {
+	switch {
+	case len(stageDefinition.Unit) > 0:
+		return stageDefinition.Unit
+	case len(stage.PostCheck.Meta.Unit) > 0:
+		return stage.PostCheck.Meta.Unit
+	case len(renderer.Unit()) > 0:
+		return renderer.Unit()
+	}
+
+	return "rows"
+}
+
+func formatValue(value int64, unit string) string {
+	if unit == "byte" {
+		return ByteCountSI(value)
+	}
+
+	return fmt.Sprintf("%s %s", humanize.FormatInteger("# ###,", int(value)), unit)
+}
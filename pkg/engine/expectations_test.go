@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datatok/tintin/pkg/executions"
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/reporting"
+	"github.com/datatok/tintin/pkg/utils/constant"
+)
+
+func TestEvaluateExpectations(t *testing.T) {
+	min, max := int64(50000), int64(100000)
+
+	tests := []struct {
+		name       string
+		definition pipelines.StageDefinition
+		run        string
+		status     string
+		value      int
+		expected   reporting.Status
+	}{
+		{
+			name:     "no expectation",
+			status:   constant.DoneError,
+			expected: reporting.Status{Status: constant.DoneError, Details: "djobi"},
+		},
+		{
+			name:       "below min",
+			definition: pipelines.StageDefinition{MinValue: &min},
+			status:     constant.DoneOk,
+			value:      1204,
+			expected:   reporting.Status{Status: constant.DoneError, Details: "1 204 documents, expected at least 50 000 documents"},
+		},
+		{
+			name:       "above max, custom unit",
+			definition: pipelines.StageDefinition{MaxValue: &max, Unit: "lines"},
+			status:     constant.DoneOk,
+			value:      123456,
+			expected:   reporting.Status{Status: constant.DoneError, Details: "123 456 lines, expected at most 100 000 lines"},
+		},
+		{
+			name:       "within bounds, upgraded",
+			definition: pipelines.StageDefinition{MinValue: &min, MaxValue: &max},
+			status:     constant.DoneError,
+			value:      60000,
+			expected:   reporting.Status{Status: constant.DoneOk, Details: "60 000 documents, as expected"},
+		},
+		{
+			name:       "within bounds, run failed",
+			definition: pipelines.StageDefinition{MinValue: &min, MaxValue: &max},
+			run:        constant.DoneError,
+			status:     constant.DoneError,
+			value:      60000,
+			expected:   reporting.Status{Status: constant.DoneError, Details: "djobi"},
+		},
+		{
+			name:       "below min, run failed",
+			definition: pipelines.StageDefinition{MinValue: &min},
+			run:        constant.DoneError,
+			status:     constant.DoneOk,
+			value:      1204,
+			expected:   reporting.Status{Status: constant.DoneError, Details: "1 204 documents, expected at least 50 000 documents"},
+		},
+		{
+			name:       "within bounds",
+			definition: pipelines.StageDefinition{MinValue: &min},
+			status:     constant.DoneOk,
+			value:      60000,
+			expected:   reporting.Status{Status: constant.DoneOk, Details: "djobi"},
+		},
+		{
+			name:       "allow empty",
+			definition: pipelines.StageDefinition{MinValue: &min, AllowEmpty: true},
+			status:     constant.DoneError,
+			expected:   reporting.Status{Status: constant.DoneOk, Details: "no documents, allowed empty"},
+		},
+		{
+			name:       "allow empty, run failed",
+			definition: pipelines.StageDefinition{AllowEmpty: true},
+			run:        constant.DoneError,
+			status:     constant.DoneError,
+			expected:   reporting.Status{Status: constant.DoneError, Details: "djobi"},
+		},
+		{
+			name:       "no post-check",
+			definition: pipelines.StageDefinition{MinValue: &min},
+			status:     constant.Todo,
+			expected:   reporting.Status{Status: constant.Todo, Details: "djobi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := executions.StageHit{Kind: "org.elasticsearch.output", Status: tt.run}
+			stage.PostCheck.Status = tt.status
+			stage.PostCheck.Meta.Value = tt.value
+
+			status := evaluateExpectations(tt.definition, stage, elasticsearchRenderer{}, reporting.Status{Status: tt.status, Details: "djobi"})
+
+			assert.Equal(t, tt.expected, status)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/datatok/tintin/pkg/executions"
+	"github.com/datatok/tintin/pkg/utils/constant"
+	"github.com/datatok/tintin/pkg/utils/links"
+)
+
+// Magic link to documents of an elasticsearch output
+const ElasticsearchConsoFrontURL = "es_conso"
+
+func init() {
+	RegisterStageRenderer("elasticsearch", elasticsearchRenderer{})
+}
+
+/**
+ * Documents written to an elasticsearch index, linked to the "es_conso" magic link.
+ */
+type elasticsearchRenderer struct{}
+
+func (elasticsearchRenderer) Render(stage executions.StageHit, urls links.Repository) (string, string) {
+	phase := stage.PostCheck
+	link := urls.Generate(ElasticsearchConsoFrontURL, map[string]string{"index": phase.Meta.Index, "query": phase.Meta.Query})
+
+	switch {
+	case phase.Status == constant.DoneOk:
+		return fmt.Sprintf("%s documents in %s", humanize.FormatInteger("# ###,", phase.Meta.Value), phase.Meta.Index), link
+	case phase.Meta.Value == 0:
+		return fmt.Sprintf("no document in %s", phase.Meta.Index), link
+	}
+
+	return genericRenderer{}.Render(stage, urls)
+}
+
+func (elasticsearchRenderer) Unit() string {
+	return "documents"
+}
@@ -5,8 +5,26 @@ import (
 
 	"github.com/datatok/tintin/pkg/executions"
 	"github.com/datatok/tintin/pkg/utils/constant"
+	"github.com/datatok/tintin/pkg/utils/links"
 )
 
+func init() {
+	RegisterStageRenderer("scp", scpRenderer{})
+}
+
+/**
+ * Files copied with scp.
+ */
+type scpRenderer struct{}
+
+func (scpRenderer) Render(stage executions.StageHit, urls links.Repository) (string, string) {
+	return SCPBuildDisplay(stage), stage.PostCheck.Link
+}
+
+func (scpRenderer) Unit() string {
+	return ""
+}
+
 func SCPBuildDisplay(stage executions.StageHit) string {
 	phase := stage.PostCheck
 
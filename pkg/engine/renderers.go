@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/datatok/tintin/pkg/executions"
+	"github.com/datatok/tintin/pkg/utils/constant"
+	"github.com/datatok/tintin/pkg/utils/links"
+)
+
+// Renderer of stages without a renderer for their kind
+const GenericStageRenderer = "generic"
+
+// StageRenderer renders the post-check of stages of a kind (elasticsearch output, scp...).
+type StageRenderer interface {
+	// Render details text & link of a done (ok or error) post-check
+	Render(stage executions.StageHit, urls links.Repository) (details string, link string)
+
+	// Unit of post-check value, when neither the definition nor the execution log tells (empty if unknown)
+	Unit() string
+}
+
+var stageRenderers = map[string]StageRenderer{}
+
+// RegisterStageRenderer registers a renderer, for stage kinds (such as "org.elasticsearch.output") or a part of them (such as "elasticsearch").
+func RegisterStageRenderer(name string, renderer StageRenderer) {
+	stageRenderers[name] = renderer
+}
+
+func init() {
+	RegisterStageRenderer(GenericStageRenderer, genericRenderer{})
+}
+
+/**
+ * Renderer of a (resolved) stage kind: registered for the kind, else for a part of it
+ * ("kafka" for "io.djobi.kafka.output"), else for a substring of it ("elasticsearch" for
+ * "elasticsearch7-output"), longest name first, else the generic one.
+ */
+func lookupStageRenderer(kind string) StageRenderer {
+	if renderer, ok := stageRenderers[kind]; ok {
+		return renderer
+	}
+
+	parts := make(map[string]bool)
+
+	for _, part := range strings.FieldsFunc(strings.ToLower(kind), isKindSeparator) {
+		parts[part] = true
+	}
+
+	names := make([]string, 0, len(stageRenderers))
+
+	for name := range stageRenderers {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		if name != GenericStageRenderer && parts[name] {
+			return stageRenderers[name]
+		}
+	}
+
+	for _, name := range names {
+		if name != GenericStageRenderer && strings.Contains(strings.ToLower(kind), name) {
+			return stageRenderers[name]
+		}
+	}
+
+	return stageRenderers[GenericStageRenderer]
+}
+
+func isKindSeparator(r rune) bool {
+	return r == '.' || r == '-' || r == '_' || r == '/'
+}
+
+/**
+ * Any stage: reason, else value with its unit, else display.
+ */
+type genericRenderer struct{}
+
+func (genericRenderer) Render(stage executions.StageHit, urls links.Repository) (string, string) {
+	phase := stage.PostCheck
+
+	switch {
+	case len(phase.Meta.Reason) > 0:
+		return phase.Meta.Reason, phase.Link
+	case phase.Status == constant.DoneOk && len(phase.Meta.Unit) > 0:
+		return formatMetaValue(phase.Meta), phase.Link
+	}
+
+	return phase.Meta.Display, phase.Link
+}
+
+func (genericRenderer) Unit() string {
+	return ""
+}
+
+func formatMetaValue(meta executions.Meta) string {
+	return formatValue(int64(meta.Value), meta.Unit)
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datatok/tintin/pkg/executions"
+	"github.com/datatok/tintin/pkg/utils/constant"
+	"github.com/datatok/tintin/pkg/utils/links"
+)
+
+type kafkaRenderer struct{}
+
+func (kafkaRenderer) Render(stage executions.StageHit, urls links.Repository) (string, string) {
+	return "messages in " + stage.PostCheck.Meta.Index, ""
+}
+
+func (kafkaRenderer) Unit() string {
+	return "messages"
+}
+
+func TestStageKinds_Renderer(t *testing.T) {
+	RegisterStageRenderer("kafka", kafkaRenderer{})
+	defer delete(stageRenderers, "kafka")
+
+	kinds := DefaultStageKinds()
+
+	tests := []struct {
+		kind     string
+		expected StageRenderer
+	}{
+		{"org.elasticsearch.output", elasticsearchRenderer{}},
+		{"elasticsearch", elasticsearchRenderer{}},
+		{"io.djobi.scp.output", scpRenderer{}},
+		{"io.djobi.kafka.output", kafkaRenderer{}},
+		{"elasticsearch7-output", elasticsearchRenderer{}},
+		{"scpfile", scpRenderer{}},
+		{"io.djobi.scp.elasticsearchlike", scpRenderer{}},
+		{"io.djobi.kafkaesque.output", kafkaRenderer{}},
+		{"org.apache.parquet.output", genericRenderer{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			assert.Equal(t, tt.expected, kinds.Renderer(tt.kind))
+		})
+	}
+}
+
+func TestLoadStageKinds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stage_kinds.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte("aliases:\n  jdbc: io.djobi.jdbc.output\nrenderers:\n  org.opensearch.output: elasticsearch\n"), 0644))
+
+	kinds, err := LoadStageKinds(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "org.elasticsearch.output", kinds.Resolve("elasticsearch"))
+	assert.Equal(t, "io.djobi.jdbc.output", kinds.Resolve("jdbc"))
+	assert.Equal(t, "io.djobi.s3.output", kinds.Resolve("io.djobi.s3.output"))
+	assert.Equal(t, elasticsearchRenderer{}, kinds.Renderer("org.opensearch.output"))
+
+	require.NoError(t, os.WriteFile(path, []byte("renderers:\n  io.djobi.jdbc.output: jdbc\n"), 0644))
+
+	_, err = LoadStageKinds(path)
+
+	assert.EqualError(t, err, `stage kind io.djobi.jdbc.output: unknown renderer "jdbc"`)
+}
+
+func TestGenericRenderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		meta     executions.Meta
+		expected string
+	}{
+		{"reason", constant.DoneOk, executions.Meta{Reason: "ok", Value: 12, Unit: "rows"}, "ok"},
+		{"value", constant.DoneOk, executions.Meta{Value: 1200, Unit: "rows", Display: "1200"}, "1 200 rows"},
+		{"bytes", constant.DoneOk, executions.Meta{Value: 1500000, Unit: "byte"}, "1.5 MB"},
+		{"display", constant.DoneOk, executions.Meta{Display: "3 files"}, "3 files"},
+		{"error", constant.DoneError, executions.Meta{Value: 0, Unit: "rows", Display: "empty"}, "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := executions.StageHit{Kind: "io.djobi.jdbc.output"}
+			stage.PostCheck.Status = tt.status
+			stage.PostCheck.Meta = tt.meta
+			stage.PostCheck.Link = "https://jdbc"
+
+			details, link := genericRenderer{}.Render(stage, links.Repository{})
+
+			assert.Equal(t, tt.expected, details)
+			assert.Equal(t, "https://jdbc", link)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+/**
+ * Stage kinds configuration:
+ * - aliases of legacy or short kinds, like "elasticsearch: org.elasticsearch.output"
+ * - renderers of kinds, by registered renderer name, like "org.opensearch.output: elasticsearch"
+ */
+type StageKinds struct {
+	Aliases   map[string]string
+	Renderers map[string]string
+}
+
+/**
+ * Built-in aliases, of old stage definitions.
+ */
+func DefaultStageKinds() StageKinds {
+	return StageKinds{
+		Aliases: map[string]string{
+			"elasticsearch": "org.elasticsearch.output",
+		},
+		Renderers: map[string]string{},
+	}
+}
+
+/**
+ * Load stage kinds YAML file, over built-in aliases.
+ */
+func LoadStageKinds(path string) (StageKinds, error) {
+	var file StageKinds
+
+	kinds := DefaultStageKinds()
+
+	if len(path) == 0 {
+		return kinds, nil
+	}
+
+	dat, err := os.ReadFile(path)
+
+	if err != nil {
+		return kinds, err
+	}
+
+	if err := yaml.UnmarshalStrict(dat, &file); err != nil {
+		return kinds, fmt.Errorf("invalid stage kinds file %s: %w", path, err)
+	}
+
+	for alias, kind := range file.Aliases {
+		kinds.Aliases[alias] = kind
+	}
+
+	for kind, name := range file.Renderers {
+		if _, ok := stageRenderers[name]; !ok {
+			return kinds, fmt.Errorf("stage kind %s: unknown renderer %q", kind, name)
+		}
+
+		kinds.Renderers[kind] = name
+	}
+
+	return kinds, nil
+}
+
+/**
+ * Resolve stage kind alias.
+ */
+func (k StageKinds) Resolve(stageKind string) string {
+	if kind, ok := k.Aliases[stageKind]; ok {
+		return kind
+	}
+
+	return stageKind
+}
+
+/**
+ * Renderer of the stage kind: from configuration, else from registered renderers.
+ */
+func (k StageKinds) Renderer(stageKind string) StageRenderer {
+	kind := k.Resolve(stageKind)
+
+	if name, ok := k.Renderers[kind]; ok {
+		return stageRenderers[name]
+	}
+
+	return lookupStageRenderer(kind)
+}
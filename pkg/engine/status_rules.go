@@ -0,0 +1,269 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/datatok/tintin/pkg/executions"
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/reporting"
+	"github.com/datatok/tintin/pkg/utils/constant"
+)
+
+const (
+	// Rule set of pipelines without one, built-in unless overridden by the status rules file
+	DefaultStatusRuleSet = "default"
+
+	// Checked stages: output, failed & pre-check failed stages (else all executed ones), or all enabled stages
+	StatusRulesStagesOutput = "output"
+	StatusRulesStagesAll    = "all"
+)
+
+// Stage phases of rule conditions
+var statusRulePhases = map[string]func(stage reporting.WorkStageDetails) string{
+	"pre_check":  func(stage reporting.WorkStageDetails) string { return stage.PreCheck.Status },
+	"run":        func(stage reporting.WorkStageDetails) string { return stage.Run.Status },
+	"post_check": func(stage reporting.WorkStageDetails) string { return stage.PostCheck.Status },
+	"resume":     func(stage reporting.WorkStageDetails) string { return stage.Resume.Status },
+}
+
+// Work statuses of rules, by severity: the most severe stage status is the work one
+var statusRuleSeverities = map[string]int{
+	constant.DoneOk:      0,
+	constant.DoneUnknown: 1,
+	constant.DoneError:   2,
+}
+
+/**
+ * Statuses of stage phases, like "run: [DONE_ERROR]": matches if every phase status is listed.
+ */
+type StatusCondition map[string][]string
+
+/**
+ * Status of a stage matching "when" and not "unless" conditions (empty conditions are ignored).
+ * Message variables are {stage}, {pre_check}, {run}, {post_check}, {resume} (statuses) and {details}.
+ */
+type StatusRule struct {
+	When    StatusCondition
+	Unless  StatusCondition
+	Status  string
+	Message string
+}
+
+/**
+ * Rules applied in order to each checked stage, the first matching one gives stage status (else "otherwise").
+ */
+type StatusRuleSet struct {
+	Stages    string
+	Rules     []StatusRule
+	Otherwise string
+}
+
+/**
+ * Status rules file: rule sets by name, and rule sets of teams.
+ */
+type StatusRules struct {
+	Teams    map[string]string
+	RuleSets map[string]StatusRuleSet `yaml:"rule_sets"`
+}
+
+/**
+ * Built-in rules: a stage run not ok, or a post-check in error, is an error;
+ * a post-check not done is unknown.
+ */
+func DefaultStatusRules() StatusRules {
+	return StatusRules{
+		Teams: map[string]string{},
+		RuleSets: map[string]StatusRuleSet{
+			DefaultStatusRuleSet: {
+				Stages: StatusRulesStagesOutput,
+				Rules: []StatusRule{
+					{Unless: StatusCondition{"run": {constant.DoneOk}}, Status: constant.DoneError},
+					{When: StatusCondition{"resume": {constant.DoneError}}, Status: constant.DoneError},
+					{When: StatusCondition{"resume": {constant.Todo, constant.DoneUnknown, constant.InProgress}}, Status: constant.DoneUnknown},
+				},
+				Otherwise: constant.DoneOk,
+			},
+		},
+	}
+}
+
+/**
+ * Load status rules YAML file, over built-in rules.
+ */
+func LoadStatusRules(path string) (StatusRules, error) {
+	var file StatusRules
+
+	rules := DefaultStatusRules()
+
+	if len(path) == 0 {
+		return rules, nil
+	}
+
+	dat, err := os.ReadFile(path)
+
+	if err != nil {
+		return rules, err
+	}
+
+	if err := yaml.UnmarshalStrict(dat, &file); err != nil {
+		return rules, fmt.Errorf("invalid status rules file %s: %w", path, err)
+	}
+
+	for name, set := range file.RuleSets {
+		if err := set.validate(); err != nil {
+			return DefaultStatusRules(), fmt.Errorf("status rule set %s: %w", name, err)
+		}
+
+		if len(set.Stages) == 0 {
+			set.Stages = StatusRulesStagesOutput
+		}
+
+		if len(set.Otherwise) == 0 {
+			set.Otherwise = constant.DoneOk
+		}
+
+		rules.RuleSets[name] = set
+	}
+
+	for team, name := range file.Teams {
+		if _, ok := rules.RuleSets[name]; !ok {
+			return DefaultStatusRules(), fmt.Errorf("team %s: unknown status rule set %q", team, name)
+		}
+
+		rules.Teams[team] = name
+	}
+
+	return rules, nil
+}
+
+func (set StatusRuleSet) validate() error {
+	if set.Stages != "" && set.Stages != StatusRulesStagesOutput && set.Stages != StatusRulesStagesAll {
+		return fmt.Errorf("invalid stages %q, expected %s or %s", set.Stages, StatusRulesStagesOutput, StatusRulesStagesAll)
+	}
+
+	if _, ok := statusRuleSeverities[set.Otherwise]; set.Otherwise != "" && !ok {
+		return fmt.Errorf("invalid otherwise status %q", set.Otherwise)
+	}
+
+	for i, rule := range set.Rules {
+		if _, ok := statusRuleSeverities[rule.Status]; !ok {
+			return fmt.Errorf("rule %d: invalid status %q, expected %s, %s or %s", i+1, rule.Status, constant.DoneOk, constant.DoneUnknown, constant.DoneError)
+		}
+
+		for _, condition := range []StatusCondition{rule.When, rule.Unless} {
+			for phase := range condition {
+				if _, ok := statusRulePhases[phase]; !ok {
+					return fmt.Errorf("rule %d: unknown phase %q, expected pre_check, run, post_check or resume", i+1, phase)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Rule set of the pipeline: from its reporting definition, else from its team, else the default one.
+ * An unknown rule set falls back to the default one, with an error.
+ */
+func (r StatusRules) RuleSet(pipeline pipelines.Definition) (StatusRuleSet, error) {
+	name := pipeline.Reporting.StatusRules
+
+	if len(name) == 0 {
+		name = r.Teams[pipeline.Team]
+	}
+
+	if len(name) == 0 {
+		name = DefaultStatusRuleSet
+	}
+
+	if set, ok := r.RuleSets[name]; ok {
+		return set, nil
+	}
+
+	return r.RuleSets[DefaultStatusRuleSet], fmt.Errorf("unknown status rule set %q, default rules are used", name)
+}
+
+/**
+ * Is the stage checked: every enabled one, or output stages & failed ones.
+ */
+func (set StatusRuleSet) checksStage(stageDefinition pipelines.StageDefinition, stageExecution *executions.StageHit) bool {
+	return set.Stages == StatusRulesStagesAll ||
+		stageDefinition.IsOutputStage() ||
+		(stageExecution != nil && stageExecution.Status == constant.DoneError)
+}
+
+/**
+ * Work status & message, of the most severe stage status (first stage by name if several).
+ */
+func (set StatusRuleSet) Evaluate(stages map[string]reporting.WorkStageDetails) (string, string) {
+	names := make([]string, 0, len(stages))
+
+	for name := range stages {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	status, message := "", ""
+
+	for _, name := range names {
+		stageStatus, stageMessage := set.evaluateStage(name, stages[name])
+
+		if len(status) == 0 || statusRuleSeverities[stageStatus] > statusRuleSeverities[status] {
+			status, message = stageStatus, stageMessage
+		}
+	}
+
+	return status, message
+}
+
+func (set StatusRuleSet) evaluateStage(name string, stage reporting.WorkStageDetails) (string, string) {
+	for _, rule := range set.Rules {
+		if rule.When.matches(stage) && (len(rule.Unless) == 0 || !rule.Unless.matches(stage)) {
+			return rule.Status, formatStatusMessage(rule.Message, name, stage)
+		}
+	}
+
+	return set.Otherwise, ""
+}
+
+func (c StatusCondition) matches(stage reporting.WorkStageDetails) bool {
+	for phase, statuses := range c {
+		if !containsStatus(statuses, statusRulePhases[phase](stage)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatStatusMessage(message string, name string, stage reporting.WorkStageDetails) string {
+	if len(message) == 0 {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{stage}", name,
+		"{pre_check}", stage.PreCheck.Status,
+		"{run}", stage.Run.Status,
+		"{post_check}", stage.PostCheck.Status,
+		"{resume}", stage.Resume.Status,
+		"{details}", stage.Resume.Details,
+	).Replace(message)
+}
@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/reporting"
+	"github.com/datatok/tintin/pkg/utils/constant"
+)
+
+const testStatusRules = `
+teams:
+  team_b: lenient
+rule_sets:
+  lenient:
+    rules:
+      - when: {run: [DONE_OK], post_check: [TODO, NO]}
+        status: DONE_OK
+      - unless: {run: [DONE_OK]}
+        status: DONE_ERROR
+        message: "{stage} run is {run}"
+      - when: {resume: [DONE_ERROR]}
+        status: DONE_ERROR
+        message: "{stage}: {details}"
+  strict:
+    stages: all
+    rules:
+      - unless: {run: [DONE_OK], resume: [DONE_OK]}
+        status: DONE_ERROR
+    otherwise: DONE_OK
+`
+
+func testStage(run string, postCheck string) reporting.WorkStageDetails {
+	return reporting.WorkStageDetails{
+		Run:       reporting.Status{Status: run},
+		PostCheck: reporting.Status{Status: postCheck},
+		Resume:    reporting.Status{Status: postCheck, Details: "no document in conso"},
+	}
+}
+
+func TestStatusRuleSet_Evaluate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status_rules.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(testStatusRules), 0644))
+
+	rules, err := LoadStatusRules(path)
+
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		set             string
+		stages          map[string]reporting.WorkStageDetails
+		expectedStatus  string
+		expectedMessage string
+	}{
+		{
+			name:           "default ok",
+			set:            DefaultStatusRuleSet,
+			stages:         map[string]reporting.WorkStageDetails{"output": testStage(constant.DoneOk, constant.DoneOk)},
+			expectedStatus: constant.DoneOk,
+		},
+		{
+			name:           "default post-check missing",
+			set:            DefaultStatusRuleSet,
+			stages:         map[string]reporting.WorkStageDetails{"output": testStage(constant.DoneOk, constant.Todo)},
+			expectedStatus: constant.DoneUnknown,
+		},
+		{
+			name: "default error over unknown",
+			set:  DefaultStatusRuleSet,
+			stages: map[string]reporting.WorkStageDetails{
+				"a": testStage(constant.DoneOk, constant.Todo),
+				"b": testStage(constant.DoneOk, constant.DoneError),
+				"c": testStage(constant.DoneOk, constant.DoneOk),
+			},
+			expectedStatus: constant.DoneError,
+		},
+		{
+			name:           "default stage not found",
+			set:            DefaultStatusRuleSet,
+			stages:         map[string]reporting.WorkStageDetails{"output": testStage(constant.No, constant.No)},
+			expectedStatus: constant.DoneError,
+		},
+		{
+			name:           "lenient post-check missing",
+			set:            "lenient",
+			stages:         map[string]reporting.WorkStageDetails{"output": testStage(constant.DoneOk, constant.Todo)},
+			expectedStatus: constant.DoneOk,
+		},
+		{
+			name:            "lenient message",
+			set:             "lenient",
+			stages:          map[string]reporting.WorkStageDetails{"output": testStage(constant.DoneOk, constant.DoneError)},
+			expectedStatus:  constant.DoneError,
+			expectedMessage: "output: no document in conso",
+		},
+		{
+			name:            "lenient run error",
+			set:             "lenient",
+			stages:          map[string]reporting.WorkStageDetails{"output": testStage(constant.DoneError, constant.No)},
+			expectedStatus:  constant.DoneError,
+			expectedMessage: "output run is DONE_ERROR",
+		},
+		{
+			name:           "strict post-check missing",
+			set:            "strict",
+			stages:         map[string]reporting.WorkStageDetails{"output": testStage(constant.DoneOk, constant.Todo)},
+			expectedStatus: constant.DoneError,
+		},
+		{
+			name:           "strict run error",
+			set:            "strict",
+			stages:         map[string]reporting.WorkStageDetails{"output": testStage(constant.DoneError, constant.DoneOk)},
+			expectedStatus: constant.DoneError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := rules.RuleSets[tt.set].Evaluate(tt.stages)
+
+			assert.Equal(t, tt.expectedStatus, status)
+			assert.Equal(t, tt.expectedMessage, message)
+		})
+	}
+}
+
+func TestStatusRules_RuleSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status_rules.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(testStatusRules), 0644))
+
+	rules, err := LoadStatusRules(path)
+
+	require.NoError(t, err)
+
+	set, err := rules.RuleSet(pipelines.Definition{Team: "team_a"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusRulesStagesOutput, set.Stages)
+	assert.Len(t, set.Rules, 3)
+
+	set, err = rules.RuleSet(pipelines.Definition{Team: "team_b"})
+	require.NoError(t, err)
+	assert.Equal(t, rules.RuleSets["lenient"], set)
+
+	set, err = rules.RuleSet(pipelines.Definition{Team: "team_b", Reporting: pipelines.ReportingDefinition{StatusRules: "strict"}})
+	require.NoError(t, err)
+	assert.Equal(t, StatusRulesStagesAll, set.Stages)
+	assert.True(t, set.checksStage(pipelines.StageDefinition{Kind: "io.djobi.filter"}, nil))
+
+	set, err = rules.RuleSet(pipelines.Definition{Reporting: pipelines.ReportingDefinition{StatusRules: "relaxed"}})
+	assert.EqualError(t, err, `unknown status rule set "relaxed", default rules are used`)
+	assert.Equal(t, rules.RuleSets[DefaultStatusRuleSet], set)
+	assert.False(t, set.checksStage(pipelines.StageDefinition{Kind: "io.djobi.filter"}, nil))
+}
+
+func TestLoadStatusRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"status", "rule_sets:\n  a:\n    rules:\n      - status: OK\n", `status rule set a: rule 1: invalid status "OK", expected DONE_OK, DONE_UNKNOWN or DONE_ERROR`},
+		{"phase", "rule_sets:\n  a:\n    rules:\n      - when: {post: [TODO]}\n        status: DONE_OK\n", `status rule set a: rule 1: unknown phase "post", expected pre_check, run, post_check or resume`},
+		{"stages", "rule_sets:\n  a:\n    stages: failed\n", `status rule set a: invalid stages "failed", expected output or all`},
+		{"team", "teams:\n  team_a: relaxed\n", `team team_a: unknown status rule set "relaxed"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "status_rules.yaml")
+
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			_, err := LoadStatusRules(path)
+
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package executions
+
+import (
+	"net/url"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+/**
+ * Djobi executions of a schedule: from elasticsearch indices, or from exported NDJSON files.
+ */
+type Backend interface {
+	// Fetch job executions of the schedule, then stages of the checked ones (matched by selected pipelines)
+	FetchJobsExecutions() error
+	FetchStagesOfJobs(jobExecutionUIDs []string) error
+
+	MatchJobExecutions(pipeline pipelines.Definition, workName string) JobExecutionMatch
+	FetchStagesExecutions(jobExecutionUID string) ([]StageHit, error)
+
+	// Logs of a job execution, and of its stages (for links)
+	JobLogs(jobExecutionUID string) LogsQuery
+	StagesLogs(jobExecutionUID string) LogsQuery
+}
+
+type LogsQuery struct {
+	Index, Query string
+}
+
+/**
+ * Backend of METRICS_LOG_API_URL: "file://" URL for NDJSON exports, elasticsearch else.
+ */
+func NewBackend(settings *cli.EnvSettings, scheduleTitle string) Backend {
+	if path, ok := fileBackendPath(settings); ok {
+		return NewFileBackend(path, settings, scheduleTitle)
+	}
+
+	return &ElasticsearchBackend{
+		Jobs:   NewJobsStore(settings, scheduleTitle),
+		Stages: NewStagesStore(settings, scheduleTitle),
+	}
+}
+
+/**
+ * Path of NDJSON exports, if METRICS_LOG_API_URL is a "file://" URL.
+ */
+func fileBackendPath(settings *cli.EnvSettings) (string, bool) {
+	if u, err := url.Parse(settings.MetricsLogAPIURL); err == nil && u.Scheme == "file" {
+		return u.Host + u.Path, true
+	}
+
+	return "", false
+}
+
+/**
+ * Djobi jobs & stages indices.
+ */
+type ElasticsearchBackend struct {
+	Jobs   *JobsStore
+	Stages *StagesStore
+}
+
+func (b *ElasticsearchBackend) FetchJobsExecutions() error {
+	return b.Jobs.FetchJobsExecutions()
+}
+
+func (b *ElasticsearchBackend) FetchStagesOfJobs(jobExecutionUIDs []string) error {
+	return b.Stages.FetchStagesOfJobs(jobExecutionUIDs)
+}
+
+func (b *ElasticsearchBackend) MatchJobExecutions(pipeline pipelines.Definition, workName string) JobExecutionMatch {
+	return b.Jobs.MatchJobExecutions(pipeline, workName)
+}
+
+func (b *ElasticsearchBackend) FetchStagesExecutions(jobExecutionUID string) ([]StageHit, error) {
+	return b.Stages.FetchStagesExecutions(jobExecutionUID)
+}
+
+func (b *ElasticsearchBackend) JobLogs(jobExecutionUID string) LogsQuery {
+	return LogsQuery{Index: b.Jobs.StoreName, Query: "_id:" + jobExecutionUID}
+}
+
+func (b *ElasticsearchBackend) StagesLogs(jobExecutionUID string) LogsQuery {
+	return LogsQuery{Index: b.Stages.StoreName, Query: b.Stages.JobUIDField + ":" + jobExecutionUID}
+}
@@ -0,0 +1,36 @@
+package executions
+
+import "errors"
+
+// ErrUnavailable is returned when the elasticsearch client could not be created.
+var ErrUnavailable = errors.New("elasticsearch is not available")
+
+// ErrTruncated is returned when some executions of the schedule were not fetched (max executions).
+var ErrTruncated = errors.New("job executions are truncated")
+
+/**
+ * Error fetching executions from a data source (djobi index).
+ */
+type DataSourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *DataSourceError) Error() string {
+	return e.Source + ": " + e.Err.Error()
+}
+
+func (e *DataSourceError) Unwrap() error {
+	return e.Err
+}
+
+/**
+ * Error of a store without client.
+ */
+func unavailableError(source string, clientErr error) error {
+	if clientErr == nil {
+		clientErr = ErrUnavailable
+	}
+
+	return &DataSourceError{Source: source, Err: clientErr}
+}
@@ -1,41 +1,32 @@
 package executions
 
 import (
-	"crypto/tls"
+	"fmt"
+
 	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/esclient"
 	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/sirupsen/logrus"
-	"net/http"
-	"strings"
-	"time"
 )
 
-func getElasticsearchClient(settings *cli.EnvSettings) *elasticsearch.Client {
-	es, err := elasticsearch.NewClient(elasticsearch.Config{
-		Addresses:  strings.Split(settings.MetricsLogAPIURL, ","),
-		MaxRetries: 2,
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost:   10,
-			ResponseHeaderTimeout: time.Second,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-	})
+func getElasticsearchClient(settings *cli.EnvSettings) (*elasticsearch.Client, error) {
+	es, err := esclient.New(settings, settings.MetricsLogAPIURL)
 
 	if err != nil {
 		logrus.Warnf("nop, cannot connect to elasticsearch: %s", err)
-		return nil
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
 	}
 
 	i, err := es.Info()
 
 	if err != nil {
 		logrus.Warnf("nop, cannot connect to elasticsearch: %s", err)
-		return nil
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
 	}
 
+	defer i.Body.Close()
+
 	logrus.Infof("connected: %s", i.String())
 
-	return es
+	return es, nil
 }
@@ -0,0 +1,269 @@
+package executions
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+// Longest NDJSON line (document)
+const maxDocumentSize = 16 * 1024 * 1024
+
+/**
+ * Djobi jobs & stages documents exported as NDJSON, one file per index ("djobi-jobs.ndjson"),
+ * or a directory per index ("djobi-jobs/*.ndjson"). Lines are documents, or hits with "_source".
+ */
+type FileBackend struct {
+	Path string
+
+	jobsIndex, stagesIndex     string
+	scheduleField, jobUIDField string
+	scheduleTitle              string
+	jobExecutions              []JobExecution
+	stagesByJobUID             map[string][]StageHit
+}
+
+func NewFileBackend(path string, settings *cli.EnvSettings, scheduleTitle string) *FileBackend {
+	return &FileBackend{
+		Path:          path,
+		jobsIndex:     settings.MetricsLogJobsIndex,
+		stagesIndex:   settings.MetricsLogStagesIndex,
+		scheduleField: settings.MetricsLogScheduleField,
+		jobUIDField:   settings.MetricsLogJobUIDField,
+		scheduleTitle: scheduleTitle,
+	}
+}
+
+func (b *FileBackend) Status() string {
+	files, err := b.indexFiles(b.jobsIndex)
+
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	return fmt.Sprintf("ok: %d files of %s in %s", len(files), b.jobsIndex, b.Path)
+}
+
+/**
+ * Read job executions of the schedule.
+ */
+func (b *FileBackend) FetchJobsExecutions() error {
+	b.jobExecutions = nil
+
+	err := b.readIndex(b.jobsIndex, func(id string, fields map[string]interface{}, source []byte) error {
+		if documentField(fields, b.scheduleField) != b.scheduleTitle {
+			return nil
+		}
+
+		var jobExecution JobExecution
+
+		if err := json.Unmarshal(source, &jobExecution); err != nil {
+			return err
+		}
+
+		if len(jobExecution.UID) == 0 {
+			jobExecution.UID = id
+		}
+
+		b.jobExecutions = append(b.jobExecutions, jobExecution)
+
+		return nil
+	})
+
+	logrus.Infof("read %d job executions from %s", len(b.jobExecutions), b.Path)
+
+	return err
+}
+
+/**
+ * Read stages of job executions, by job UID.
+ */
+func (b *FileBackend) FetchStagesOfJobs(jobExecutionUIDs []string) error {
+	b.stagesByJobUID = make(map[string][]StageHit)
+
+	for _, uid := range jobExecutionUIDs {
+		b.stagesByJobUID[uid] = nil
+	}
+
+	err := b.readIndex(b.stagesIndex, func(id string, fields map[string]interface{}, source []byte) error {
+		uid := documentField(fields, b.jobUIDField)
+
+		if _, ok := b.stagesByJobUID[uid]; !ok {
+			return nil
+		}
+
+		var stage StageHit
+
+		if err := json.Unmarshal(source, &stage); err != nil {
+			return err
+		}
+
+		b.stagesByJobUID[uid] = append(b.stagesByJobUID[uid], stage)
+
+		return nil
+	})
+
+	for uid, stages := range b.stagesByJobUID {
+		b.stagesByJobUID[uid] = fixLegacyStages(stages)
+	}
+
+	return err
+}
+
+func (b *FileBackend) MatchJobExecutions(pipeline pipelines.Definition, workName string) JobExecutionMatch {
+	return matchJobExecutions(b.jobExecutions, pipeline, workName)
+}
+
+func (b *FileBackend) FetchStagesExecutions(jobExecutionUID string) ([]StageHit, error) {
+	if b.stagesByJobUID == nil {
+		uids := make([]string, 0, len(b.jobExecutions))
+
+		for _, jobExecution := range b.jobExecutions {
+			uids = append(uids, jobExecution.UID)
+		}
+
+		if err := b.FetchStagesOfJobs(uids); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.stagesByJobUID[jobExecutionUID], nil
+}
+
+func (b *FileBackend) JobLogs(jobExecutionUID string) LogsQuery {
+	return LogsQuery{Index: b.jobsIndex, Query: "_id:" + jobExecutionUID}
+}
+
+func (b *FileBackend) StagesLogs(jobExecutionUID string) LogsQuery {
+	return LogsQuery{Index: b.stagesIndex, Query: b.jobUIDField + ":" + jobExecutionUID}
+}
+
+/**
+ * Read documents of all files of the index (comma separated names or patterns).
+ */
+func (b *FileBackend) readIndex(index string, read func(id string, fields map[string]interface{}, source []byte) error) error {
+	files, err := b.indexFiles(index)
+
+	if err == nil && len(files) == 0 {
+		err = fmt.Errorf("no NDJSON file of %s in %s", index, b.Path)
+	}
+
+	if err != nil {
+		return &DataSourceError{Source: index, Err: err}
+	}
+
+	for _, file := range files {
+		if err := readDocuments(file, read); err != nil {
+			return &DataSourceError{Source: index, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (b *FileBackend) indexFiles(index string) ([]string, error) {
+	var ret []string
+
+	for _, name := range indices(index) {
+		files, err := filepath.Glob(filepath.Join(b.Path, name+".ndjson"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		dirs, _ := filepath.Glob(filepath.Join(b.Path, name))
+
+		for _, dir := range dirs {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				dirFiles, _ := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+
+				files = append(files, dirFiles...)
+			}
+		}
+
+		sort.Strings(files)
+
+		ret = append(ret, files...)
+	}
+
+	return ret, nil
+}
+
+/**
+ * Read NDJSON documents: "_source" of hits, or the line itself.
+ */
+func readDocuments(file string, read func(id string, fields map[string]interface{}, source []byte) error) error {
+	f, err := os.Open(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxDocumentSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		content := scanner.Bytes()
+
+		if len(strings.TrimSpace(string(content))) == 0 {
+			continue
+		}
+
+		var hit struct {
+			ID     string          `json:"_id"`
+			Source json.RawMessage `json:"_source"`
+		}
+
+		if err := json.Unmarshal(content, &hit); err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+
+		if len(hit.Source) > 0 {
+			content = hit.Source
+		}
+
+		var fields map[string]interface{}
+
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+
+		if err := read(hit.ID, fields, content); err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+/**
+ * Value of a document field, like "meta.title.keyword" (nested or dotted keys).
+ */
+func documentField(fields map[string]interface{}, field string) string {
+	field = strings.TrimSuffix(field, ".keyword")
+
+	if value, ok := fields[field]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+
+	parts := strings.SplitN(field, ".", 2)
+
+	if len(parts) == 2 {
+		if nested, ok := fields[parts[0]].(map[string]interface{}); ok {
+			return documentField(nested, parts[1])
+		}
+	}
+
+	return ""
+}
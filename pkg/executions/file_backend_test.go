@@ -0,0 +1,82 @@
+package executions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+func TestFileBackend(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+
+	// Index as a directory of exports, hits or documents
+	write("djobi-jobs-2021.10/part-1.ndjson", `{"_id": "j1", "_source": {"id": "conso", "meta": {"title": "21/10/2021"}, "pipeline": {"name": "team_a/conso"}}}`+"\n")
+	write("djobi-jobs-2021.10/part-2.ndjson", `{"uid": "j2", "id": "conso", "meta": {"title": "20/10/2021"}, "pipeline": {"name": "team_a/conso"}}`+"\n")
+
+	// Index as a file, flat field names
+	write("djobi-stages.ndjson", `{"job.uid": "j1", "type": "org.elasticsearch.output", "post_check": {"meta": {"size": "12 documents"}}}`+"\n"+
+		`{"job.uid": "j2", "type": "org.elasticsearch.output"}`+"\n")
+
+	settings := &cli.EnvSettings{
+		MetricsLogAPIURL:        "file://" + dir,
+		MetricsLogJobsIndex:     "djobi-jobs-*",
+		MetricsLogStagesIndex:   "djobi-stages",
+		MetricsLogScheduleField: "meta.title.keyword",
+		MetricsLogJobUIDField:   "job.uid",
+	}
+
+	backend := NewBackend(settings, "21/10/2021")
+
+	require.IsType(t, &FileBackend{}, backend)
+	require.NoError(t, backend.FetchJobsExecutions())
+	require.NoError(t, backend.FetchStagesOfJobs([]string{"j1"}))
+
+	jobExecution := backend.MatchJobExecutions(pipelines.Definition{FullName: "team_a/conso"}, "conso").Latest()
+
+	if assert.NotNil(t, jobExecution) {
+		assert.Equal(t, "j1", jobExecution.UID, "UID from hit _id")
+	}
+
+	stages, err := backend.FetchStagesExecutions("j1")
+
+	if assert.NoError(t, err) && assert.Len(t, stages, 1) {
+		assert.Equal(t, 12, stages[0].PostCheck.Meta.Value)
+	}
+
+	stages, err = backend.FetchStagesExecutions("j2")
+
+	assert.NoError(t, err)
+	assert.Empty(t, stages, "stages of other schedules are not read")
+
+	assert.Equal(t, LogsQuery{Index: "djobi-stages", Query: "job.uid:j1"}, backend.StagesLogs("j1"))
+
+	t.Run("missing index", func(t *testing.T) {
+		backend := NewFileBackend(dir, &cli.EnvSettings{MetricsLogJobsIndex: "djobi-jobs"}, "21/10/2021")
+
+		var dataSourceError *DataSourceError
+
+		if assert.ErrorAs(t, backend.FetchJobsExecutions(), &dataSourceError) {
+			assert.Equal(t, "djobi-jobs", dataSourceError.Source)
+		}
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		write("djobi-stages.ndjson", "{\n")
+
+		err := backend.FetchStagesOfJobs([]string{"j1"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "djobi-stages.ndjson:1")
+	})
+}
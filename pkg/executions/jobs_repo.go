@@ -2,14 +2,18 @@ package executions
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
 	"github.com/sirupsen/logrus"
 
 	"github.com/datatok/tintin/pkg/pipelines"
 	"github.com/datatok/tintin/pkg/utils"
 	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/esclient"
 )
 
 type SparkExecutor struct {
@@ -38,8 +42,9 @@ type JobExecution struct {
 }
 
 type JobSearchAPIResponse struct {
-	Took int
-	Hits struct {
+	Took  int
+	PitID string `json:"pit_id"`
+	Hits  struct {
 		Total struct {
 			Value int
 		}
@@ -54,88 +59,271 @@ type JobSearchAPIResponse struct {
 
 type JobsStore struct {
 	client              *elasticsearch.Client
+	clientErr           error
 	StoreName           string
+	ScheduleField       string
 	filterScheduleTitle string
+	maxExecutions       int
 	JobExecutions       []JobExecution
+
+	// Executions matching the schedule, and if some were not fetched (max executions)
+	Total     int
+	Truncated bool
 }
 
+/**
+ * Status of the executions backend: NDJSON files found, or elasticsearch info (a single request).
+ */
 func GetServiceStatus(settings *cli.EnvSettings) string {
-	es := getElasticsearchClient(settings)
+	if path, ok := fileBackendPath(settings); ok {
+		return NewFileBackend(path, settings, "").Status()
+	}
+
+	es, err := esclient.New(settings, settings.MetricsLogAPIURL)
+
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
 	i, err := es.Info()
 
-	if err == nil {
-		return i.String()
+	if err != nil {
+		return "error: " + err.Error()
 	}
 
-	return "error: " + err.Error()
+	defer i.Body.Close()
+
+	return i.String()
 }
 
 func NewJobsStore(settings *cli.EnvSettings, scheduleTitle string) *JobsStore {
-	es := getElasticsearchClient(settings)
+	es, err := getElasticsearchClient(settings)
 
 	return &JobsStore{
 		client:              es,
-		StoreName:           "djobi-jobs",
+		clientErr:           err,
+		StoreName:           settings.MetricsLogJobsIndex,
+		ScheduleField:       settings.MetricsLogScheduleField,
 		filterScheduleTitle: scheduleTitle,
+		maxExecutions:       settings.MetricsLogMaxExecutions,
 	}
 }
 
-// Fetch djobi-jobs
-//
-func (c *JobsStore) FetchJobsExecutions() {
-	var (
-		r JobSearchAPIResponse
-	)
+const (
+	// Executions fetched per request
+	jobsPageSize = 1000
 
-	client := c.client
+	// Paging without point in time is limited by "index.max_result_window"
+	maxResultWindow = 10000
 
-	res, err := client.Search(
-		client.Search.WithIndex(c.StoreName),
-		client.Search.WithQuery("meta.title.keyword:"+strings.Replace(c.filterScheduleTitle, "/", "\\/", -1)),
-		client.Search.WithSize(1000),
-	)
+	pointInTimeKeepAlive = "1m"
+)
+
+/**
+ * Fetch djobi-jobs of the schedule, page by page: with a point in time & "search_after",
+ * or with "from" when point in time is not available (up to 10000 executions).
+ * Stop at maxExecutions (0 means no limit).
+ * A failed page returns a DataSourceError, with executions fetched so far; missing executions
+ * return a DataSourceError wrapping ErrTruncated.
+ */
+func (c *JobsStore) FetchJobsExecutions() error {
+	c.JobExecutions = nil
+	c.Total, c.Truncated = 0, false
+
+	if c.client == nil {
+		return unavailableError(c.StoreName, c.clientErr)
+	}
+
+	pit, err := c.openPointInTime()
 
 	if err != nil {
-		logrus.Errorf("Error getting response: %s", err)
+		logrus.Warnf("point in time not available, fetching up to %d job executions: %s", maxResultWindow, err)
 	} else {
-		defer res.Body.Close()
-
-		if res.IsError() {
-			var e map[string]interface{}
-			if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
-				logrus.Errorf("Error parsing the response body: %s", err)
-			} else {
-				// Print the response status and error information.
-				logrus.Errorf("[%s] %s: %s",
-					res.Status(),
-					e["error"].(map[string]interface{})["type"],
-					e["error"].(map[string]interface{})["reason"],
-				)
-			}
-		} else {
-			if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-				logrus.Errorf("Error parsing the response body: %s", err)
-			}
+		defer c.closePointInTime(&pit)
+	}
 
-			for _, hit := range r.Hits.Hits {
-				c.JobExecutions = append(c.JobExecutions, hit.Source)
-			}
+	var searchAfter []interface{}
+
+	for {
+		size := jobsPageSize
+
+		if c.maxExecutions > 0 && c.maxExecutions-len(c.JobExecutions) < size {
+			size = c.maxExecutions - len(c.JobExecutions)
+		}
+
+		if len(pit) == 0 && maxResultWindow-len(c.JobExecutions) < size {
+			size = maxResultWindow - len(c.JobExecutions)
 		}
+
+		if size <= 0 {
+			break
+		}
+
+		r, err := c.searchPage(pit, searchAfter, size)
+
+		if err != nil {
+			logrus.Errorf("Error fetching job executions: %s", err)
+			return &DataSourceError{Source: c.StoreName, Err: err}
+		}
+
+		c.Total = r.Hits.Total.Value
+
+		if len(r.PitID) > 0 {
+			pit = r.PitID
+		}
+
+		for _, hit := range r.Hits.Hits {
+			c.JobExecutions = append(c.JobExecutions, hit.Source)
+		}
+
+		if len(r.Hits.Hits) < size {
+			break
+		}
+
+		searchAfter = r.Hits.Hits[len(r.Hits.Hits)-1].Sort
 	}
+
+	c.Truncated = len(c.JobExecutions) < c.Total
+
+	if c.Truncated {
+		logrus.Warnf("fetched %d of %d job executions (max: %d)", len(c.JobExecutions), c.Total, c.maxExecutions)
+
+		return &DataSourceError{
+			Source: c.StoreName,
+			Err:    fmt.Errorf("%w: fetched %d of %d, works without execution may have one", ErrTruncated, len(c.JobExecutions), c.Total),
+		}
+	}
+
+	logrus.Infof("fetched %d job executions", len(c.JobExecutions))
+
+	return nil
+}
+
+func (c *JobsStore) searchPage(pit string, searchAfter []interface{}, size int) (*JobSearchAPIResponse, error) {
+	var r JobSearchAPIResponse
+
+	client := c.client
+
+	body := map[string]interface{}{
+		"size":             size,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"query_string": map[string]interface{}{
+				"query": c.ScheduleField + ":" + strings.Replace(c.filterScheduleTitle, "/", "\\/", -1),
+			},
+		},
+	}
+
+	var options []func(*esapi.SearchRequest)
+
+	if len(pit) > 0 {
+		body["pit"] = map[string]interface{}{"id": pit, "keep_alive": pointInTimeKeepAlive}
+		body["sort"] = []interface{}{"_shard_doc"}
+
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+	} else {
+		body["from"] = len(c.JobExecutions)
+		options = append(options, client.Search.WithIndex(indices(c.StoreName)...))
+	}
+
+	// Body is complete: the reader encodes it lazily
+	options = append(options, client.Search.WithBody(esutil.NewJSONReader(body)))
+
+	res, err := client.Search(options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, responseError(res)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("unable to parse response body: %w", err)
+	}
+
+	return &r, nil
+}
+
+func (c *JobsStore) openPointInTime() (string, error) {
+	var r struct {
+		ID string `json:"id"`
+	}
+
+	res, err := c.client.OpenPointInTime(indices(c.StoreName), pointInTimeKeepAlive)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", responseError(res)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return "", err
+	}
+
+	return r.ID, nil
+}
+
+func (c *JobsStore) closePointInTime(pit *string) {
+	if len(*pit) == 0 {
+		return
+	}
+
+	res, err := c.client.ClosePointInTime(c.client.ClosePointInTime.WithBody(esutil.NewJSONReader(map[string]string{"id": *pit})))
+
+	if err != nil {
+		logrus.Debugf("unable to close point in time: %s", err)
+		return
+	}
+
+	res.Body.Close()
 }
 
 /**
- * Get the pipeline jobs execution
+ * Comma separated index names, patterns or data streams.
  */
-func (c *JobsStore) FindJobExecution(pipeline pipelines.Definition, id string) *JobExecution {
+func indices(names string) []string {
+	var ret []string
 
-	for _, jobExecution := range c.JobExecutions {
-		if (jobExecution.Pipeline.Name == pipeline.FullName || strings.HasSuffix(pipeline.FullName, jobExecution.Pipeline.Name)) && jobExecution.ID == id {
-			jobExecution.Pipeline.Definition = pipeline
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			ret = append(ret, name)
+		}
+	}
+
+	return ret
+}
 
-			return &jobExecution
+/**
+ * Error of an ES error response: "[400 Bad Request] type: reason".
+ */
+func responseError(res *esapi.Response) error {
+	var e struct {
+		Error struct {
+			Type, Reason string
 		}
 	}
 
-	return nil
+	if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
+		return fmt.Errorf("[%s] unable to parse error response body: %w", res.Status(), err)
+	}
+
+	return fmt.Errorf("[%s] %s: %s", res.Status(), e.Error.Type, e.Error.Reason)
+}
+
+/**
+ * Executions of the pipeline work.
+ */
+func (c *JobsStore) MatchJobExecutions(pipeline pipelines.Definition, workName string) JobExecutionMatch {
+	return matchJobExecutions(c.JobExecutions, pipeline, workName)
 }
@@ -0,0 +1,177 @@
+package executions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+/**
+ * Fake djobi-jobs index of n executions, with or without point in time support.
+ */
+func newFakeJobsServer(t *testing.T, n int, pit bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/djobi-jobs/_pit":
+			if !pit {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error": {"type": "parse_exception", "reason": "no pit"}}`)
+				return
+			}
+
+			fmt.Fprint(w, `{"id": "pit-1"}`)
+		case r.URL.Path == "/_pit":
+			fmt.Fprint(w, `{"succeeded": true}`)
+		case n < 0 && r.URL.Path != "/":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error": {"type": "search_phase_execution_exception", "reason": "all shards failed"}}`)
+		case r.URL.Path == "/_search" || r.URL.Path == "/djobi-jobs/_search":
+			var body struct {
+				Size        int
+				From        int
+				SearchAfter []int `json:"search_after"`
+				Pit         *struct{ ID string }
+			}
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+			from := body.From
+
+			if len(body.SearchAfter) > 0 {
+				from = body.SearchAfter[0] + 1
+			}
+
+			var hits []map[string]interface{}
+
+			for i := from; i < n && i < from+body.Size; i++ {
+				hits = append(hits, map[string]interface{}{
+					"_id":     fmt.Sprint(i),
+					"_source": map[string]interface{}{"id": fmt.Sprint(i)},
+					"sort":    []int{i},
+				})
+			}
+
+			response := map[string]interface{}{
+				"hits": map[string]interface{}{
+					"total": map[string]interface{}{"value": n},
+					"hits":  hits,
+				},
+			}
+
+			if body.Pit != nil {
+				response["pit_id"] = body.Pit.ID
+			}
+
+			json.NewEncoder(w).Encode(response)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+}
+
+func TestJobsStore_FetchJobsExecutions(t *testing.T) {
+	tests := []struct {
+		name                    string
+		total, max              int
+		pit                     bool
+		expectedCount           int
+		expectedTruncatedResult bool
+	}{
+		{"single page", 12, 0, true, 12, false},
+		{"several pages with point in time", 2500, 0, true, 2500, false},
+		{"max executions", 2500, 1500, true, 1500, true},
+		{"several pages without point in time", 2500, 0, false, 2500, false},
+		{"without point in time, up to max result window", 10500, 0, false, 10000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeJobsServer(t, tt.total, tt.pit)
+			defer server.Close()
+
+			client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+			require.NoError(t, err)
+
+			store := &JobsStore{client: client, StoreName: "djobi-jobs", ScheduleField: "meta.title.keyword", filterScheduleTitle: "21/10/2021", maxExecutions: tt.max}
+
+			err = store.FetchJobsExecutions()
+
+			if tt.expectedTruncatedResult {
+				var dataSourceError *DataSourceError
+
+				assert.ErrorIs(t, err, ErrTruncated)
+				assert.ErrorAs(t, err, &dataSourceError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Len(t, store.JobExecutions, tt.expectedCount)
+			assert.Equal(t, tt.total, store.Total)
+			assert.Equal(t, tt.expectedTruncatedResult, store.Truncated)
+		})
+	}
+}
+
+func TestJobsStore_FetchJobsExecutions_Errors(t *testing.T) {
+	t.Run("search error", func(t *testing.T) {
+		server := newFakeJobsServer(t, -1, false)
+		defer server.Close()
+
+		client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+		require.NoError(t, err)
+
+		store := &JobsStore{client: client, StoreName: "djobi-jobs", ScheduleField: "meta.title.keyword", filterScheduleTitle: "21/10/2021"}
+
+		err = store.FetchJobsExecutions()
+
+		var dataSourceError *DataSourceError
+
+		if assert.ErrorAs(t, err, &dataSourceError) {
+			assert.Equal(t, "djobi-jobs", dataSourceError.Source)
+			assert.Contains(t, err.Error(), "all shards failed")
+		}
+	})
+
+	t.Run("no client", func(t *testing.T) {
+		store := &JobsStore{StoreName: "djobi-jobs"}
+
+		err := store.FetchJobsExecutions()
+
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Empty(t, store.JobExecutions)
+	})
+}
+
+func TestGetServiceStatus(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"version": {"number": "7.17.0"}, "tagline": "You Know, for Search"}`)
+	}))
+	defer server.Close()
+
+	status := GetServiceStatus(&cli.EnvSettings{MetricsLogAPIURL: server.URL})
+
+	assert.Contains(t, status, "7.17.0")
+	assert.Equal(t, 2, requests, "product check of the client, then a single info request")
+
+	status = GetServiceStatus(&cli.EnvSettings{MetricsLogAPIURL: "file://testdata/none", MetricsLogJobsIndex: "djobi-jobs"})
+
+	assert.Equal(t, "ok: 0 files of djobi-jobs in testdata/none", status)
+	assert.Equal(t, 2, requests, "no request to elasticsearch for files")
+}
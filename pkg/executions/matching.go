@@ -0,0 +1,174 @@
+package executions
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+)
+
+const (
+	// Executions matched by pipeline UID, from meta.id
+	MatchByID = "id"
+
+	// Executions matched by pipeline full name
+	MatchByName = "name"
+
+	// Executions matched by a short pipeline name, ending the pipeline full name (legacy djobi logs)
+	MatchBySuffix = "suffix"
+)
+
+/**
+ * Executions of a pipeline work, oldest first.
+ */
+type JobExecutionMatch struct {
+	Executions []JobExecution
+
+	// How executions were matched: MatchByID, MatchByName or MatchBySuffix
+	By string
+
+	// UIDs of the pipelines sharing the full name, when executions of several were matched
+	Conflicts []string
+}
+
+/**
+ * Latest execution (by timeline start), nil if none.
+ */
+func (m JobExecutionMatch) Latest() *JobExecution {
+	if len(m.Executions) == 0 {
+		return nil
+	}
+
+	return &m.Executions[len(m.Executions)-1]
+}
+
+/**
+ * Are executions of several pipelines matched (same full name, no meta.id)
+ */
+func (m JobExecutionMatch) IsAmbiguous() bool {
+	return len(m.Conflicts) > 1
+}
+
+/**
+ * Pipeline names of matched executions.
+ */
+func (m JobExecutionMatch) PipelineNames() []string {
+	var ret []string
+
+	seen := make(map[string]bool)
+
+	for _, jobExecution := range m.Executions {
+		if name := jobExecution.Pipeline.Name; !seen[name] {
+			seen[name] = true
+			ret = append(ret, name)
+		}
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+/**
+ * Is the short pipeline name (like "archivr") the end of the pipeline full name (like "team_a/archivr").
+ */
+func IsPipelineSuffix(name string, fullName string) bool {
+	return len(name) > 0 && name != fullName && strings.HasSuffix(fullName, "/"+name)
+}
+
+/**
+ * Match executions of the pipeline work: by pipeline UID if the pipeline has a meta.id,
+ * then by pipeline full name, then by short pipeline name as a last resort (legacy djobi logs).
+ * Executions of another pipeline UID never match a pipeline with meta.id.
+ */
+func matchJobExecutions(jobExecutions []JobExecution, pipeline pipelines.Definition, workName string) JobExecutionMatch {
+	var byID, byName, bySuffix []JobExecution
+
+	id := pipeline.Meta.ID
+
+	for _, jobExecution := range jobExecutions {
+		if jobExecution.Pipeline == nil || jobExecution.ID != workName {
+			continue
+		}
+
+		switch {
+		case len(id) > 0 && jobExecution.Pipeline.UID == id:
+			byID = append(byID, jobExecution)
+		case len(id) > 0 && len(jobExecution.Pipeline.UID) > 0:
+			continue
+		case jobExecution.Pipeline.Name == pipeline.FullName:
+			byName = append(byName, jobExecution)
+		case IsPipelineSuffix(jobExecution.Pipeline.Name, pipeline.FullName):
+			bySuffix = append(bySuffix, jobExecution)
+		}
+	}
+
+	if len(byID) > 0 {
+		return JobExecutionMatch{Executions: sortJobExecutions(byID), By: MatchByID}
+	}
+
+	ret := JobExecutionMatch{Executions: sortJobExecutions(byName), By: MatchByName}
+
+	if len(byName) == 0 && len(bySuffix) > 0 {
+		ret = JobExecutionMatch{Executions: sortJobExecutions(bySuffix), By: MatchBySuffix}
+	}
+
+	seen := make(map[string]bool)
+
+	for _, jobExecution := range ret.Executions {
+		uid := jobExecution.Pipeline.UID
+
+		if len(uid) > 0 && !seen[uid] {
+			seen[uid] = true
+			ret.Conflicts = append(ret.Conflicts, uid)
+		}
+	}
+
+	if !ret.IsAmbiguous() {
+		ret.Conflicts = nil
+	}
+
+	sort.Strings(ret.Conflicts)
+
+	return ret
+}
+
+// Layouts of timeline start: RFC 3339, or with an offset without colon (djobi, like "+0000")
+var timelineLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700"}
+
+/**
+ * Parse timeline start, false if empty or unparseable.
+ */
+func parseTimelineStart(start string) (time.Time, bool) {
+	for _, layout := range timelineLayouts {
+		if t, err := time.Parse(layout, start); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+/**
+ * Oldest first, by timeline start then UID (deterministic).
+ * Executions without a parseable start (like a running retry) are last: the latest one decides.
+ */
+func sortJobExecutions(jobExecutions []JobExecution) []JobExecution {
+	sort.SliceStable(jobExecutions, func(i, j int) bool {
+		a, b := jobExecutions[i], jobExecutions[j]
+		aStart, aOk := parseTimelineStart(a.Timeline.Start)
+		bStart, bOk := parseTimelineStart(b.Timeline.Start)
+
+		switch {
+		case aOk != bOk:
+			return aOk
+		case aOk && !aStart.Equal(bStart):
+			return aStart.Before(bStart)
+		}
+
+		return a.UID < b.UID
+	})
+
+	return jobExecutions
+}
@@ -0,0 +1,139 @@
+package executions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+)
+
+func TestMatchJobExecutions(t *testing.T) {
+	execution := func(uid, pipelineUID, pipelineName, start string) JobExecution {
+		ret := JobExecution{UID: uid, ID: "archivr"}
+		ret.Pipeline = &struct {
+			UID, Name  string
+			Definition pipelines.Definition
+		}{UID: pipelineUID, Name: pipelineName}
+		ret.Timeline.Start = start
+
+		return ret
+	}
+
+	jobExecutions := []JobExecution{
+		execution("a-retry", "p-a", "team_a/archivr", "2021-10-22T04:00:00.000+0000"),
+		execution("a", "p-a", "team_a/archivr", "2021-10-22T02:00:00.000+0000"),
+		execution("b", "p-b", "team_b/archivr", "2021-10-22T02:00:00.000+0000"),
+		execution("legacy", "", "archivr", "2021-10-22T02:00:00.000+0000"),
+		execution("c1", "p-c1", "team_c/archivr", "2021-10-22T02:00:00.000+0000"),
+		execution("c2", "p-c2", "team_c/archivr", "2021-10-22T03:00:00.000+0000"),
+		execution("d-renamed", "p-d", "team_d/old_name", "2021-10-22T02:00:00.000+0000"),
+		execution("d-other", "p-other", "team_d/archivr", "2021-10-22T03:00:00.000+0000"),
+		execution("d-no-uid", "", "team_d/archivr", "2021-10-22T01:00:00.000+0000"),
+	}
+
+	tests := []struct {
+		name              string
+		pipeline          pipelines.Definition
+		expectedUIDs      []string
+		expectedBy        string
+		expectedConflicts []string
+	}{
+		{
+			name:         "by full name, retries oldest first",
+			pipeline:     pipelines.Definition{FullName: "team_a/archivr"},
+			expectedUIDs: []string{"a", "a-retry"},
+			expectedBy:   MatchByName,
+		},
+		{
+			name:         "full name before short name",
+			pipeline:     pipelines.Definition{FullName: "team_b/archivr"},
+			expectedUIDs: []string{"b"},
+			expectedBy:   MatchByName,
+		},
+		{
+			name:              "several pipelines with the same full name",
+			pipeline:          pipelines.Definition{FullName: "team_c/archivr"},
+			expectedUIDs:      []string{"c1", "c2"},
+			expectedBy:        MatchByName,
+			expectedConflicts: []string{"p-c1", "p-c2"},
+		},
+		{
+			name:         "by meta.id first",
+			pipeline:     pipelines.Definition{FullName: "team_d/archivr", Meta: pipelines.MetaDefinition{ID: "p-d"}},
+			expectedUIDs: []string{"d-renamed"},
+			expectedBy:   MatchByID,
+		},
+		{
+			name:         "meta.id without execution, executions of other pipeline UIDs are ignored",
+			pipeline:     pipelines.Definition{FullName: "team_d/archivr", Meta: pipelines.MetaDefinition{ID: "p-new"}},
+			expectedUIDs: []string{"d-no-uid"},
+			expectedBy:   MatchByName,
+		},
+		{
+			name:         "by short name, as last resort",
+			pipeline:     pipelines.Definition{FullName: "team_e/archivr"},
+			expectedUIDs: []string{"legacy"},
+			expectedBy:   MatchBySuffix,
+		},
+		{
+			name:       "short name is a full directory",
+			pipeline:   pipelines.Definition{FullName: "team_e/old_archivr"},
+			expectedBy: MatchByName,
+		},
+		{
+			name:       "no execution",
+			pipeline:   pipelines.Definition{FullName: "team_e/conso"},
+			expectedBy: MatchByName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := matchJobExecutions(jobExecutions, tt.pipeline, "archivr")
+
+			var uids []string
+
+			for _, jobExecution := range match.Executions {
+				uids = append(uids, jobExecution.UID)
+			}
+
+			assert.Equal(t, tt.expectedUIDs, uids)
+			assert.Equal(t, tt.expectedBy, match.By)
+			assert.Equal(t, tt.expectedConflicts, match.Conflicts)
+			assert.Equal(t, len(tt.expectedConflicts) > 0, match.IsAmbiguous())
+
+			if len(uids) > 0 {
+				assert.Equal(t, uids[len(uids)-1], match.Latest().UID)
+			} else {
+				assert.Nil(t, match.Latest())
+			}
+		})
+	}
+}
+
+func TestSortJobExecutions(t *testing.T) {
+	execution := func(uid, start string) JobExecution {
+		ret := JobExecution{UID: uid}
+		ret.Timeline.Start = start
+
+		return ret
+	}
+
+	jobExecutions := sortJobExecutions([]JobExecution{
+		execution("running", ""),
+		execution("paris-4h", "2021-10-22T04:00:00+02:00"),
+		execution("utc-3h", "2021-10-22T03:00:00Z"),
+		execution("invalid", "22/10/2021 05:00"),
+		execution("utc-1h", "2021-10-22T01:00:00.000+0000"),
+		execution("utc-2h30", "2021-10-22T02:30:00.5Z"),
+	})
+
+	var uids []string
+
+	for _, jobExecution := range jobExecutions {
+		uids = append(uids, jobExecution.UID)
+	}
+
+	assert.Equal(t, []string{"utc-1h", "paris-4h", "utc-2h30", "utc-3h", "invalid", "running"}, uids)
+}
@@ -1,13 +1,14 @@
 package executions
 
 import (
+	"bytes"
 	"encoding/json"
-	"log"
-
+	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/sirupsen/logrus"
 
 	"github.com/datatok/tintin/pkg/utils"
 	"github.com/datatok/tintin/pkg/utils/cli"
@@ -59,73 +60,205 @@ type StageSearchAPIResponse struct {
 
 type StagesStore struct {
 	client              *elasticsearch.Client
+	clientErr           error
 	StoreName           string
+	JobUIDField         string
 	filterScheduleTitle string
 	JobExecutions       []JobExecution
+
+	// Stages of batch fetched job executions, by job UID
+	byJobUID map[string][]StageHit
+
+	// Errors of batch fetched job executions, by job UID (not fetched again)
+	failed map[string]error
+}
+
+type StageMultiSearchAPIResponse struct {
+	Responses []struct {
+		StageSearchAPIResponse
+		Error *struct {
+			Type, Reason string
+		}
+	}
 }
 
+// Searches per _msearch request
+const stagesBatchSize = 100
+
 func NewStagesStore(settings *cli.EnvSettings, scheduleTitle string) *StagesStore {
-	es := getElasticsearchClient(settings)
+	es, err := getElasticsearchClient(settings)
 
 	return &StagesStore{
 		client:              es,
-		StoreName:           "djobi-stages",
+		clientErr:           err,
+		StoreName:           settings.MetricsLogStagesIndex,
+		JobUIDField:         settings.MetricsLogJobUIDField,
 		filterScheduleTitle: scheduleTitle,
 	}
 }
 
-// Fetch djobi-stages
-//
+/**
+ * Fetch djobi-stages of job executions, in a few _msearch requests, and index them by job UID.
+ * Job executions of failed searches keep their error, returned by FetchStagesExecutions.
+ */
+func (c *StagesStore) FetchStagesOfJobs(jobExecutionUIDs []string) error {
+	var ret error
+
+	c.byJobUID = make(map[string][]StageHit)
+	c.failed = make(map[string]error)
+
+	if c.client == nil {
+		ret = unavailableError(c.StoreName, c.clientErr)
+
+		for _, uid := range jobExecutionUIDs {
+			c.failed[uid] = ret
+		}
+
+		return ret
+	}
+
+	for start := 0; start < len(jobExecutionUIDs); start += stagesBatchSize {
+		end := start + stagesBatchSize
+
+		if end > len(jobExecutionUIDs) {
+			end = len(jobExecutionUIDs)
+		}
 
-func (c *StagesStore) FetchStagesExecutions(jobExecutionUID string) []StageHit {
+		if err := c.fetchStagesBatch(jobExecutionUIDs[start:end]); err != nil {
+			logrus.Errorf("Error fetching stage executions: %s", err)
+
+			ret = &DataSourceError{Source: c.StoreName, Err: err}
+
+			for _, uid := range jobExecutionUIDs[start:end] {
+				c.failed[uid] = ret
+			}
+		}
+	}
+
+	logrus.Infof("fetched stage executions of %d job executions", len(c.byJobUID))
+
+	return ret
+}
+
+func (c *StagesStore) fetchStagesBatch(jobExecutionUIDs []string) error {
 	var (
-		r   StageSearchAPIResponse
-		ret []StageHit
+		r    StageMultiSearchAPIResponse
+		body bytes.Buffer
 	)
 
 	client := c.client
+	encoder := json.NewEncoder(&body)
+
+	for _, uid := range jobExecutionUIDs {
+		encoder.Encode(map[string]interface{}{})
+		encoder.Encode(map[string]interface{}{
+			"size": 1000,
+			"query": map[string]interface{}{
+				"query_string": map[string]interface{}{"query": c.JobUIDField + ":" + uid},
+			},
+		})
+	}
 
-	res, err := client.Search(
-		client.Search.WithIndex(c.StoreName),
-		client.Search.WithQuery("job.uid:"+jobExecutionUID),
-		client.Search.WithSize(1000),
-	)
+	res, err := client.Msearch(&body, client.Msearch.WithIndex(indices(c.StoreName)...))
 
 	if err != nil {
-		log.Fatalf("Error getting response: %s", err)
+		return err
 	}
 
 	defer res.Body.Close()
 
 	if res.IsError() {
-		var e map[string]interface{}
-		if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
-			log.Fatalf("Error parsing the response body: %s", err)
-		} else {
-			// Print the response status and error information.
-			log.Fatalf("[%s] %s: %s",
-				res.Status(),
-				e["error"].(map[string]interface{})["type"],
-				e["error"].(map[string]interface{})["reason"],
-			)
+		return responseError(res)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("unable to parse response body: %w", err)
+	}
+
+	for i, response := range r.Responses {
+		if i >= len(jobExecutionUIDs) {
+			break
 		}
-	} else {
-		if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-			log.Fatalf("Error parsing the response body: %s", err)
+
+		if response.Error != nil {
+			logrus.Warnf("unable to fetch stages of job %s: %s: %s", jobExecutionUIDs[i], response.Error.Type, response.Error.Reason)
+
+			c.failed[jobExecutionUIDs[i]] = &DataSourceError{
+				Source: c.StoreName,
+				Err:    fmt.Errorf("%s: %s", response.Error.Type, response.Error.Reason),
+			}
+
+			continue
 		}
 
-		for _, hit := range r.Hits.Hits {
-			ret = append(ret, hit.Source)
+		var stages []StageHit
+
+		for _, hit := range response.Hits.Hits {
+			stages = append(stages, hit.Source)
 		}
+
+		c.byJobUID[jobExecutionUIDs[i]] = fixLegacyStages(stages)
 	}
 
-	// Fix legacy
-	for i := range ret {
-		stage := &ret[i]
+	return nil
+}
+
+/**
+ * Stages of a job execution: from the batch fetched ones, or from djobi-stages.
+ */
+func (c *StagesStore) FetchStagesExecutions(jobExecutionUID string) ([]StageHit, error) {
+	var r StageSearchAPIResponse
+
+	if stages, ok := c.byJobUID[jobExecutionUID]; ok {
+		return stages, nil
+	}
+
+	if err, ok := c.failed[jobExecutionUID]; ok {
+		return nil, err
+	}
+
+	if c.client == nil {
+		return nil, unavailableError(c.StoreName, c.clientErr)
+	}
+
+	client := c.client
+
+	res, err := client.Search(
+		client.Search.WithIndex(indices(c.StoreName)...),
+		client.Search.WithQuery(c.JobUIDField+":"+jobExecutionUID),
+		client.Search.WithSize(1000),
+	)
+
+	if err != nil {
+		return nil, &DataSourceError{Source: c.StoreName, Err: err}
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, &DataSourceError{Source: c.StoreName, Err: responseError(res)}
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, &DataSourceError{Source: c.StoreName, Err: fmt.Errorf("unable to parse response body: %w", err)}
+	}
+
+	ret := make([]StageHit, 0, len(r.Hits.Hits))
+
+	for _, hit := range r.Hits.Hits {
+		ret = append(ret, hit.Source)
+	}
+
+	return fixLegacyStages(ret), nil
+}
+
+func fixLegacyStages(stages []StageHit) []StageHit {
+	for i := range stages {
+		stage := &stages[i]
 		stage.PostCheck.Meta.Value = fixMetaCount(&stage.PostCheck)
 	}
 
-	return ret
+	return stages
 }
 
 /**
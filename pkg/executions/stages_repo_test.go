@@ -0,0 +1,123 @@
+package executions
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagesStore_FetchStagesOfJobs(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+
+		// Product check
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+
+		atomic.AddInt32(&requests, 1)
+
+		require.Equal(t, "/djobi-stages/_msearch", r.URL.Path)
+
+		var responses []interface{}
+
+		scanner := bufio.NewScanner(r.Body)
+
+		for scanner.Scan() {
+			// Header, then query
+			if !scanner.Scan() {
+				break
+			}
+
+			var query struct {
+				Query struct {
+					QueryString struct{ Query string } `json:"query_string"`
+				}
+			}
+
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &query))
+
+			uid := strings.TrimPrefix(query.Query.QueryString.Query, "job.uid:")
+
+			if uid == "broken" {
+				responses = append(responses, map[string]interface{}{"error": map[string]string{"type": "x", "reason": "y"}})
+				continue
+			}
+
+			responses = append(responses, map[string]interface{}{
+				"hits": map[string]interface{}{
+					"hits": []interface{}{
+						map[string]interface{}{"_source": map[string]interface{}{"stage": uid + "-input", "post_check": map[string]interface{}{"meta": map[string]string{"count": "12"}}}},
+						map[string]interface{}{"_source": map[string]interface{}{"stage": uid + "-output"}},
+					},
+				},
+			})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"responses": responses})
+	}))
+	defer server.Close()
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	require.NoError(t, err)
+
+	store := &StagesStore{client: client, StoreName: "djobi-stages", JobUIDField: "job.uid"}
+
+	var uids []string
+
+	for i := 0; i < 250; i++ {
+		uids = append(uids, fmt.Sprintf("job%d", i))
+	}
+
+	assert.NoError(t, store.FetchStagesOfJobs(append(uids, "broken")))
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "batches of 100 searches")
+	assert.Len(t, store.byJobUID, 250)
+
+	stages, err := store.FetchStagesExecutions("job42")
+
+	if assert.NoError(t, err) && assert.Len(t, stages, 2) {
+		assert.Equal(t, "job42-input", stages[0].Stage)
+		assert.Equal(t, 12, stages[0].PostCheck.Meta.Value)
+	}
+
+	_, err = store.FetchStagesExecutions("broken")
+
+	var dataSourceError *DataSourceError
+
+	if assert.ErrorAs(t, err, &dataSourceError) {
+		assert.Equal(t, "djobi-stages", dataSourceError.Source)
+	}
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "stages are served from memory, failed ones are not fetched again")
+}
+
+func TestStagesStore_Unavailable(t *testing.T) {
+	store := &StagesStore{StoreName: "djobi-stages", clientErr: errors.New("connection refused")}
+
+	err := store.FetchStagesOfJobs([]string{"job1"})
+
+	assert.EqualError(t, err, "djobi-stages: connection refused")
+
+	_, err = store.FetchStagesExecutions("job1")
+
+	assert.EqualError(t, err, "djobi-stages: connection refused")
+
+	_, err = store.FetchStagesExecutions("job2")
+
+	assert.EqualError(t, err, "djobi-stages: connection refused")
+}
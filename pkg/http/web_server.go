@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 
 	"fmt"
 
@@ -26,8 +27,9 @@ import (
 )
 
 type WebServer struct {
-	Port     int
-	settings *cli.EnvSettings
+	Port       int
+	settings   *cli.EnvSettings
+	repository *pipelines.Repository
 }
 
 func NewWebServer(s *cli.EnvSettings) *WebServer {
@@ -35,10 +37,13 @@ func NewWebServer(s *cli.EnvSettings) *WebServer {
 }
 
 func (thisWebServer *WebServer) Run(out io.Writer) error {
+	// Shared by all requests, to keep pipelines git mirror & cache
+	thisWebServer.repository = pipelines.NewRepository(thisWebServer.settings)
+
 	http.HandleFunc("/status", thisWebServer.GetStatus)
 	http.HandleFunc("/", thisWebServer.HelloServer)
 	http.Handle("/favicon.ico", http.FileServer(http.Dir("./web")))
-	http.Handle("/metrics", metrics.New(thisWebServer.settings).HTTPEndpoint())
+	http.Handle("/metrics", metrics.New(thisWebServer.settings, thisWebServer.repository).HTTPEndpoint())
 
 	fmt.Printf("Starting web server, on port %d\n\n", thisWebServer.Port)
 
@@ -52,7 +57,7 @@ func (thisWebServer *WebServer) Run(out io.Writer) error {
 }
 
 func (thisWebServer *WebServer) GetStatus(out http.ResponseWriter, r *http.Request) {
-	repo := pipelines.NewRepository(thisWebServer.settings)
+	repo := thisWebServer.repository
 
 	ret := map[string]string{
 		"web_server":            "ok: because you see this...",
@@ -71,7 +76,8 @@ func (thisWebServer *WebServer) GetStatus(out http.ResponseWriter, r *http.Reque
 
 func (thisWebServer *WebServer) HelloServer(out http.ResponseWriter, r *http.Request) {
 	argTeam := getOrDefault(r.URL, "team", "")
-	argPipeline := getOrDefault(r.URL, "pipeline", "*")
+	argOwner := getOrDefault(r.URL, "owner", "")
+	argPipelines := make([]string, 0)
 	argLevel := getOrDefault(r.URL, "status", "")
 	argSchedule := getOrDefault(r.URL, "date",
 		time.Now().AddDate(0, 0, -1).Format("02/01/2006"))
@@ -80,20 +86,36 @@ func (thisWebServer *WebServer) HelloServer(out http.ResponseWriter, r *http.Req
 	if len(argLevel) > 0 {
 		argLevels = strings.Split(argLevel, ",")
 	}
+
+	// "pipeline=team_a/**,!**/sandbox/*" or several "pipeline" parameters
+	for _, v := range r.URL.Query()["pipeline"] {
+		argPipelines = append(argPipelines, strings.Split(v, ",")...)
+	}
+
 	filter := utils.Filter{
 		Schedule:  argSchedule,
 		Team:      argTeam,
-		Pipelines: argPipeline,
+		Owner:     argOwner,
+		Pipelines: argPipelines,
 		Status:    argLevels,
 	}
 
-	repo := pipelines.NewRepository(thisWebServer.settings)
-	definitions, err := repo.FindDefinitions(filter)
+	all, err := thisWebServer.repository.FindAllDefinitions()
+
+	var definitions []pipelines.Definition
+
+	if err == nil {
+		definitions, err = thisWebServer.repository.FilterDefinitions(all, filter)
+	}
 
 	if err == nil {
 		checker := engine.New(thisWebServer.settings, filter)
 
-		rp := checker.Execute(definitions)
+		rp, err := checker.Execute(definitions, all)
+
+		if err != nil {
+			logrus.Warnf("report built with data source errors: %s", err)
+		}
 
 		if len(argLevels) > 0 {
 			rp = rp.FilterByLevel(argLevels)
@@ -105,6 +127,9 @@ func (thisWebServer *WebServer) HelloServer(out http.ResponseWriter, r *http.Req
 		t := output.NewReportHTML(thisWebServer.settings.ReportHTMLTemplatePath, rp)
 
 		t.ToHTML(out)
+	} else if errors.Is(err, pipelines.ErrInvalidSelector) {
+		out.WriteHeader(400)
+		out.Write([]byte(err.Error()))
 	} else {
 		logrus.Error(err)
 
@@ -13,13 +13,15 @@ import (
 	"github.com/datatok/tintin/pkg/pipelines"
 	"github.com/datatok/tintin/pkg/utils"
 	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/constant"
 
 	"time"
 )
 
 type Metrics struct {
-	registry *prometheus.Registry
-	settings *cli.EnvSettings
+	registry   *prometheus.Registry
+	settings   *cli.EnvSettings
+	repository *pipelines.Repository
 }
 
 var (
@@ -59,38 +61,51 @@ var (
 		})
 )
 
-func New(settings *cli.EnvSettings) *Metrics {
+func New(settings *cli.EnvSettings, repository *pipelines.Repository) *Metrics {
 	r := prometheus.NewRegistry()
 
 	r.MustRegister(stagesProcessed, worksDuration, worksExecutionDetails)
 
 	return &Metrics{
-		registry: r,
-		settings: settings,
+		registry:   r,
+		settings:   settings,
+		repository: repository,
 	}
 }
 
 func (metrics *Metrics) buildMetrics() {
 	filter := utils.Filter{
-		Schedule:  time.Now().AddDate(0, 0, -1).Format("02/01/2006"),
-		Team:      "",
-		Pipelines: "",
-		Status:    make([]string, 0),
+		Schedule: time.Now().AddDate(0, 0, -1).Format("02/01/2006"),
+		Team:     "",
+		Status:   make([]string, 0),
 	}
 
-	repo := pipelines.NewRepository(metrics.settings)
-	definitions, err := repo.FindDefinitions(filter)
+	all, err := metrics.repository.FindAllDefinitions()
+
+	var definitions []pipelines.Definition
+
+	if err == nil {
+		definitions, err = metrics.repository.FilterDefinitions(all, filter)
+	}
 
 	if err == nil {
 		checker := engine.New(metrics.settings, filter)
 
-		rp := checker.Execute(definitions)
+		rp, err := checker.Execute(definitions, all)
+
+		if err != nil {
+			logrus.Warnf("metrics of unavailable works are not updated: %s", err)
+		}
 
 		for _, p := range rp.Pipelines {
 			stagesProcessed.WithLabelValues(p.Definition.Team, p.Definition.Name, p.Definition.FullName).Set(float64(p.Counters.Works))
 
 			for _, j := range p.Jobs {
 				for _, w := range j.Works {
+					if w.Status == constant.DataUnavailable {
+						continue
+					}
+
 					worksDuration.
 						WithLabelValues(p.Definition.Team, p.Definition.Name, p.Definition.FullName, j.Name, w.Name).
 						Set(float64(w.Timeline.Duration))
@@ -0,0 +1,332 @@
+package pipelines
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	DefaultContextName = "_default_"
+
+	// Work name of contexts, "{job}" only for the default context
+	DefaultWorkName = "{job}_{context}"
+)
+
+var workNameVariable = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+/**
+ * Matrix axis, like "country: [fr, de]".
+ */
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+/**
+ * Matrix of contexts: the cartesian product of axes, in declaration order.
+ */
+type MatrixDefinition []MatrixAxis
+
+/**
+ * Keep axes order, contexts are named after it.
+ */
+func (m *MatrixDefinition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var axes yaml.MapSlice
+
+	if err := unmarshal(&axes); err != nil {
+		return err
+	}
+
+	for _, item := range axes {
+		values, ok := item.Value.([]interface{})
+
+		if !ok {
+			return fmt.Errorf("matrix axis %v must be a list", item.Key)
+		}
+
+		axis := MatrixAxis{Name: fmt.Sprint(item.Key)}
+
+		for _, value := range values {
+			axis.Values = append(axis.Values, fmt.Sprint(value))
+		}
+
+		*m = append(*m, axis)
+	}
+
+	return nil
+}
+
+/**
+ * Keep context parameters as values, available in work name.
+ */
+func (c *JobContextDefinition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var parameters map[string]interface{}
+
+	if err := unmarshal(&parameters); err != nil {
+		return err
+	}
+
+	c.Values = scalarValues(parameters)
+
+	return nil
+}
+
+func scalarValues(parameters map[string]interface{}) map[string]string {
+	ret := make(map[string]string)
+
+	for key, value := range parameters {
+		switch value.(type) {
+		case string, int, int64, float64, bool:
+			ret[key] = fmt.Sprint(value)
+		}
+	}
+
+	return ret
+}
+
+/**
+ * Contexts of the matrix, named by their values joined with "_".
+ */
+func (m MatrixDefinition) contexts() []JobContextDefinition {
+	if len(m) == 0 {
+		return nil
+	}
+
+	combinations := [][]string{{}}
+
+	for _, axis := range m {
+		var next [][]string
+
+		for _, combination := range combinations {
+			for _, value := range axis.Values {
+				next = append(next, append(append([]string{}, combination...), value))
+			}
+		}
+
+		combinations = next
+	}
+
+	ret := make([]JobContextDefinition, 0, len(combinations))
+
+	for _, combination := range combinations {
+		context := JobContextDefinition{
+			Name:   strings.Join(combination, "_"),
+			Type:   ContextTypeMatrix,
+			Values: make(map[string]string),
+		}
+
+		for i, axis := range m {
+			context.Values[axis.Name] = combination[i]
+		}
+
+		ret = append(ret, context)
+	}
+
+	return ret
+}
+
+/**
+ * Load contexts file: a YAML (or JSON) list of names, or of mappings with
+ * an optional "name" (else named by their values joined with "_").
+ */
+func loadContextsFile(read SourceReader, filePath string) ([]JobContextDefinition, error) {
+	if read == nil {
+		return nil, fmt.Errorf("contexts files are not supported by this source")
+	}
+
+	filePath, err := cleanSourcePath(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	displayPath, content, err := read(filePath)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read contexts file %s: %w", filePath, err)
+	}
+
+	var (
+		names []string
+		rows  []yaml.MapSlice
+		ret   []JobContextDefinition
+	)
+
+	if err := yaml.Unmarshal(content, &names); err == nil {
+		for _, name := range names {
+			ret = append(ret, JobContextDefinition{Name: name, Type: ContextTypeFile, Values: make(map[string]string)})
+		}
+	} else if err := yaml.Unmarshal(content, &rows); err != nil {
+		return nil, fmt.Errorf("invalid contexts file %s: %w", displayPath, err)
+	}
+
+	for _, row := range rows {
+		context := JobContextDefinition{Type: ContextTypeFile, Values: make(map[string]string)}
+
+		var values []string
+
+		for _, pair := range row {
+			key, value := fmt.Sprint(pair.Key), fmt.Sprint(pair.Value)
+
+			if key == "name" {
+				context.Name = value
+			} else {
+				context.Values[key] = value
+				values = append(values, value)
+			}
+		}
+
+		if len(context.Name) == 0 {
+			context.Name = strings.Join(values, "_")
+		}
+
+		ret = append(ret, context)
+	}
+
+	for i, context := range ret {
+		if len(context.Name) == 0 {
+			return nil, fmt.Errorf("invalid contexts file %s: item %d has no name", displayPath, i)
+		}
+	}
+
+	return ret, nil
+}
+
+/**
+ * Build job contexts: listed contexts, matrix & contexts file, or the default context.
+ * Resolve work names.
+ */
+func (def *Definition) resolveContexts(job *JobDefinition, read SourceReader) {
+	jobPath := "jobs." + job.Name
+	contexts := make(map[string]JobContextDefinition)
+
+	add := func(context JobContextDefinition, source string) {
+		if _, ok := contexts[context.Name]; ok {
+			def.Errors = append(def.Errors, ValidationError{File: def.Path, Path: jobPath + "." + source, Message: fmt.Sprintf("duplicate context %q", context.Name)})
+			return
+		}
+
+		contexts[context.Name] = context
+	}
+
+	for contextName, context := range job.Contexts {
+		context.Name = contextName
+		context.Type = ContextTypeSet
+
+		add(context, "contexts")
+	}
+
+	for _, axis := range job.Matrix {
+		if len(axis.Values) == 0 {
+			def.Errors = append(def.Errors, ValidationError{File: def.Path, Path: jobPath + ".matrix." + axis.Name, Message: "matrix axis has no value"})
+		}
+	}
+
+	for _, context := range job.Matrix.contexts() {
+		add(context, "matrix")
+	}
+
+	if len(job.ContextsFile) > 0 {
+		fileContexts, err := loadContextsFile(read, path.Join(def.FullName, job.ContextsFile))
+
+		if err != nil {
+			def.Errors = append(def.Errors, ValidationError{File: def.Path, Path: jobPath + ".contexts_file", Message: err.Error()})
+		}
+
+		for _, context := range fileContexts {
+			add(context, "contexts_file")
+		}
+	}
+
+	if len(contexts) == 0 {
+		contexts[DefaultContextName] = JobContextDefinition{Name: DefaultContextName, Type: ContextTypeDefault}
+	}
+
+	workNameValid := true
+
+	names := make([]string, 0, len(contexts))
+
+	for name := range contexts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		context := contexts[name]
+		workName, err := formatWorkName(job, context)
+
+		// Report once, all contexts share the template
+		if err != nil && workNameValid {
+			workNameValid = false
+			def.Errors = append(def.Errors, ValidationError{File: def.Path, Path: jobPath + ".work_name", Message: err.Error()})
+		}
+
+		context.WorkName = workName
+		contexts[name] = context
+	}
+
+	job.Contexts = contexts
+}
+
+/**
+ * Work name, from job "work_name" template: "{job}", "{context}" & context values.
+ */
+func formatWorkName(job *JobDefinition, context JobContextDefinition) (string, error) {
+	template := job.WorkName
+
+	if len(template) == 0 {
+		if context.Type == ContextTypeDefault {
+			return job.Name, nil
+		}
+
+		template = DefaultWorkName
+	}
+
+	var err error
+
+	ret := workNameVariable.ReplaceAllStringFunc(template, func(variable string) string {
+		name := variable[1 : len(variable)-1]
+
+		switch name {
+		case "job":
+			return job.Name
+		case "context":
+			if context.Type == ContextTypeDefault {
+				return ""
+			}
+
+			return context.Name
+		}
+
+		value, ok := context.Values[name]
+
+		if !ok && err == nil {
+			err = fmt.Errorf("unknown variable %q in work name %q, for context %q", name, template, context.Name)
+		}
+
+		return value
+	})
+
+	return ret, err
+}
+
+/**
+ * Name of the work of a context, in djobi executions.
+ */
+func (job JobDefinition) ContextWorkName(context JobContextDefinition) string {
+	if len(context.WorkName) > 0 {
+		return context.WorkName
+	}
+
+	if context.Type == ContextTypeDefault {
+		return job.Name
+	}
+
+	return job.Name + "_" + context.Name
+}
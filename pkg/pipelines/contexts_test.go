@@ -0,0 +1,112 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinition_parsePipelineContexts(t *testing.T) {
+	a := assert.New(t)
+
+	read := mapSourceReader(map[string]string{
+		"team_a/conso/partners.yaml": "- name: acme\n  id: 12\n- id: 42\n  region: eu\n",
+	})
+
+	def := defaultPipelineDefinition("pipeline.yaml", "team_a/conso", "conso", "team_a", "")
+
+	def.parsePipeline([]byte(`
+jobs:
+  daily:
+    matrix:
+      country: [fr, de]
+      partner: [a, b, c]
+    work_name: "{job}-{country}-{partner}"
+    stages: {}
+  weekly:
+    contexts:
+      all:
+        label: everything
+    contexts_file: partners.yaml
+    stages: {}
+  monthly:
+    stages: {}
+`), read)
+
+	if !a.True(def.IsValid(), "%v", def.Errors) {
+		return
+	}
+
+	daily := def.Jobs["daily"]
+
+	a.Len(daily.Contexts, 6)
+
+	if a.Contains(daily.Contexts, "de_b") {
+		context := daily.Contexts["de_b"]
+
+		a.Equal(ContextTypeMatrix, context.Type)
+		a.Equal(map[string]string{"country": "de", "partner": "b"}, context.Values)
+		a.Equal("daily-de-b", daily.ContextWorkName(context))
+	}
+
+	weekly := def.Jobs["weekly"]
+
+	a.Len(weekly.Contexts, 3)
+	a.Equal("everything", weekly.Contexts["all"].Values["label"])
+	a.Equal("weekly_all", weekly.ContextWorkName(weekly.Contexts["all"]))
+	a.Equal(ContextTypeFile, weekly.Contexts["acme"].Type)
+	a.Equal("12", weekly.Contexts["acme"].Values["id"])
+	a.Contains(weekly.Contexts, "42_eu")
+
+	monthly := def.Jobs["monthly"]
+
+	a.Equal("monthly", monthly.ContextWorkName(monthly.Contexts[DefaultContextName]))
+}
+
+func TestDefinition_parsePipelineContextsErrors(t *testing.T) {
+	read := mapSourceReader(map[string]string{
+		"team_a/conso/countries.yaml": "[fr, de]\n",
+	})
+
+	tests := []struct {
+		name, content string
+		errors        []string
+	}{
+		{
+			"duplicate",
+			"jobs:\n  daily:\n    matrix:\n      country: [fr]\n    contexts_file: countries.yaml\n    stages: {}\n",
+			[]string{`pipeline.yaml: jobs.daily.contexts_file: duplicate context "fr"`},
+		},
+		{
+			"unknown work name variable",
+			"jobs:\n  daily:\n    contexts_file: countries.yaml\n    work_name: \"{job}_{country}\"\n    stages: {}\n",
+			[]string{`pipeline.yaml: jobs.daily.work_name: unknown variable "country" in work name "{job}_{country}", for context "de"`},
+		},
+		{
+			"missing contexts file",
+			"jobs:\n  daily:\n    contexts_file: ../../countries.yaml\n    stages: {}\n",
+			[]string{"pipeline.yaml: jobs.daily.contexts_file: unable to read contexts file countries.yaml: file countries.yaml not found"},
+		},
+		{
+			"out of pipelines directory",
+			"jobs:\n  daily:\n    contexts_file: ../../../countries.yaml\n    stages: {}\n",
+			[]string{"pipeline.yaml: jobs.daily.contexts_file: invalid path \"../countries.yaml\", out of pipelines directory"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := defaultPipelineDefinition("pipeline.yaml", "team_a/conso", "conso", "team_a", "")
+
+			def.parsePipeline([]byte(tt.content), read)
+
+			var errors []string
+
+			for _, err := range def.Errors {
+				errors = append(errors, err.Error())
+			}
+
+			assert.Equal(t, tt.errors, errors)
+		})
+	}
+}
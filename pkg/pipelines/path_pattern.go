@@ -0,0 +1,162 @@
+package pipelines
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+const (
+	DefaultPathPattern = "{team}/{name...}/pipeline.yaml"
+	DefaultTeam        = "steam"
+)
+
+var pathPatternVariable = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)(\.\.\.)?\}`)
+
+/**
+ * Pipeline path pattern, like "{team}/{domain}/{name}/pipeline.yaml":
+ * "{x}" captures one path segment, "{x...}" captures one or more segments.
+ */
+type PathPattern struct {
+	pattern string
+	reg     *regexp.Regexp
+}
+
+/**
+ * Compile pattern, the trailing "/pipeline.yaml" (or .yml) is optional,
+ * as patterns are matched against pipeline full name (its directory).
+ */
+func CompilePathPattern(pattern string) (*PathPattern, error) {
+	trimmed := "/" + strings.Trim(pattern, "/")
+	trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "/pipeline.yaml"), "/pipeline.yml")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("invalid path pattern %q: empty", pattern)
+	}
+
+	var (
+		buf   strings.Builder
+		names = make(map[string]bool)
+		last  = 0
+	)
+
+	buf.WriteString("^")
+
+	for _, loc := range pathPatternVariable.FindAllStringSubmatchIndex(trimmed, -1) {
+		literal := trimmed[last:loc[0]]
+
+		if strings.ContainsAny(literal, "{}") {
+			return nil, fmt.Errorf("invalid path pattern %q: bad variable near %q", pattern, literal)
+		}
+
+		name := trimmed[loc[2]:loc[3]]
+
+		if names[name] {
+			return nil, fmt.Errorf("invalid path pattern %q: duplicate variable %q", pattern, name)
+		}
+
+		names[name] = true
+
+		buf.WriteString(regexp.QuoteMeta(literal))
+
+		if loc[4] >= 0 {
+			buf.WriteString("(?P<" + name + ">.+?)")
+		} else {
+			buf.WriteString("(?P<" + name + ">[^/]+)")
+		}
+
+		last = loc[1]
+	}
+
+	literal := trimmed[last:]
+
+	if strings.ContainsAny(literal, "{}") {
+		return nil, fmt.Errorf("invalid path pattern %q: bad variable near %q", pattern, literal)
+	}
+
+	buf.WriteString(regexp.QuoteMeta(literal) + "$")
+
+	return &PathPattern{pattern: pattern, reg: regexp.MustCompile(buf.String())}, nil
+}
+
+func (p *PathPattern) String() string {
+	return p.pattern
+}
+
+/**
+ * Match pipeline full name, return named captures.
+ */
+func (p *PathPattern) Match(fullName string) (map[string]string, bool) {
+	match := p.reg.FindStringSubmatch(fullName)
+
+	if match == nil {
+		return nil, false
+	}
+
+	captures := make(map[string]string)
+
+	for i, name := range p.reg.SubexpNames() {
+		if i > 0 && len(name) > 0 {
+			captures[name] = match[i]
+		}
+	}
+
+	return captures, true
+}
+
+/**
+ * Derive pipeline team & name from its path, shared by all sources.
+ */
+type pathNaming struct {
+	pattern     *PathPattern
+	defaultTeam string
+}
+
+func orDefault(v, def string) string {
+	if len(v) == 0 {
+		return def
+	}
+
+	return v
+}
+
+func newPathNaming(settings *cli.EnvSettings) (*pathNaming, error) {
+	pattern, err := CompilePathPattern(orDefault(settings.PipelinesPathPattern, DefaultPathPattern))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathNaming{
+		pattern:     pattern,
+		defaultTeam: orDefault(settings.PipelinesDefaultTeam, DefaultTeam),
+	}, nil
+}
+
+/**
+ * Build definition from its full name: "{team}" & "{name}" captures, or default team & full name
+ * when the path does not match the pattern.
+ */
+func (n *pathNaming) definition(path, fullName, gitlabLink string) Definition {
+	team, name := n.defaultTeam, fullName
+
+	captures, ok := n.pattern.Match(fullName)
+
+	if ok {
+		if v := captures["team"]; len(v) > 0 {
+			team = v
+		}
+
+		if v := captures["name"]; len(v) > 0 {
+			name = v
+		}
+	}
+
+	def := defaultPipelineDefinition(path, fullName, name, team, gitlabLink)
+	def.PathLabels = captures
+
+	return def
+}
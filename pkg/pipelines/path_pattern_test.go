@@ -0,0 +1,89 @@
+package pipelines
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/links"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPathNaming, _ = newPathNaming(&cli.EnvSettings{})
+
+func TestCompilePathPattern(t *testing.T) {
+	tests := []struct {
+		pattern, fullName string
+		captures          map[string]string
+	}{
+		{"{team}/{name...}/pipeline.yaml", "team_a/archivr", map[string]string{"team": "team_a", "name": "archivr"}},
+		{"{team}/{name...}/pipeline.yaml", "team_a/conso/daily", map[string]string{"team": "team_a", "name": "conso/daily"}},
+		{"{team}/{name...}/pipeline.yaml", "archivr", nil},
+		{"{team}/{domain}/{name}/pipeline.yml", "team_a/sales/conso", map[string]string{"team": "team_a", "domain": "sales", "name": "conso"}},
+		{"{team}/{domain}/{name}", "team_a/conso", nil},
+		{"teams/team-{team}/{name}", "teams/team-a/conso", map[string]string{"team": "a", "name": "conso"}},
+		{"teams/team-{team}/{name}", "teams/a/conso", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.fullName, func(t *testing.T) {
+			pattern, err := CompilePathPattern(tt.pattern)
+
+			if assert.NoError(t, err) {
+				captures, ok := pattern.Match(tt.fullName)
+
+				assert.Equal(t, tt.captures != nil, ok)
+				assert.Equal(t, tt.captures, captures)
+			}
+		})
+	}
+
+	for _, pattern := range []string{"", "/pipeline.yaml", "{team}/{team}", "{team/{name}", "{team}/{na-me}"} {
+		_, err := CompilePathPattern(pattern)
+
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestPathNaming(t *testing.T) {
+	dir := t.TempDir()
+
+	for path, content := range map[string]string{
+		"team_a/archivr/pipeline.yaml":        "jobs: {}\n",
+		"team_a/sales/conso/pipeline.yaml":    "jobs: {}\n",
+		"archivr/pipeline.yml":                "jobs: {}\n",
+		"team_b/sales/transfer/pipeline.yaml": "jobs: {}\nmeta:\n  team: team_c\n",
+	} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755)
+		os.WriteFile(filepath.Join(dir, path), []byte(content), 0644)
+	}
+
+	naming, err := newPathNaming(&cli.EnvSettings{
+		PipelinesPathPattern: "{team}/{domain}/{name}/pipeline.yaml",
+		PipelinesDefaultTeam: "nobody",
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	definitions, err := walkDefinitions(dir, "", "file://"+dir, nil, naming, links.Repository{})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	got := make(map[string][]string)
+
+	for _, def := range definitions {
+		got[def.FullName] = []string{def.Team, def.Name, def.PathLabels["domain"]}
+	}
+
+	assert.Equal(t, map[string][]string{
+		"archivr":               {"nobody", "archivr", ""},
+		"team_a/archivr":        {"nobody", "team_a/archivr", ""},
+		"team_a/sales/conso":    {"team_a", "conso", "sales"},
+		"team_b/sales/transfer": {"team_c", "transfer", "sales"},
+	}, got)
+}
@@ -1,13 +1,14 @@
 package pipelines
 
 import (
-	"io/ioutil"
-	"regexp"
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/datatok/tintin/pkg/utils"
 	"github.com/datatok/tintin/pkg/utils/cli"
 	"github.com/datatok/tintin/pkg/utils/links"
+	"github.com/datatok/tintin/pkg/utils/myglob"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -16,25 +17,45 @@ import (
 const (
 	ContextTypeSet     = "set"
 	ContextTypeDefault = "default"
+	ContextTypeMatrix  = "matrix"
+	ContextTypeFile    = "file"
 	GitlabURL          = "pipelines_gitlab"
 )
 
-type repository interface {
-	getDefinitions() ([]Definition, error)
-}
-
 type StageDefinition struct {
 	Name, Stage, Enabled, Kind string
+
+	// Expectations on post-check value, evaluated by tintin
+	MinValue   *int64 `yaml:"min_value"`
+	MaxValue   *int64 `yaml:"max_value"`
+	Unit       string
+	AllowEmpty bool `yaml:"allow_empty"`
 }
 
 type JobContextDefinition struct {
 	Name, Type string
+
+	// Context parameters, matrix axes or contexts file values
+	Values map[string]string
+
+	// Name of the work in djobi executions
+	WorkName string
 }
 
 type JobDefinition struct {
 	Name     string
 	Stages   map[string]StageDefinition
 	Contexts map[string]JobContextDefinition
+
+	// Contexts generated from axes, or loaded from a file next to pipeline.yaml
+	Matrix       MatrixDefinition
+	ContextsFile string `yaml:"contexts_file"`
+
+	// Work name template, like "{job}_{country}"
+	WorkName string `yaml:"work_name"`
+
+	// Overrides pipeline schedule
+	Schedule *ScheduleDefinition
 }
 
 type MetaOwnerDefinition struct {
@@ -42,12 +63,60 @@ type MetaOwnerDefinition struct {
 }
 
 type MetaDefinition struct {
+	// Pipeline UID in djobi executions, to match them by UID rather than by full name
+	ID string
+
 	Team   string
 	Owners []MetaOwnerDefinition
 }
 
 type ReportingDefinition struct {
-	Enabled bool `default:true`
+	Enabled bool `default:"true"`
+
+	// Rule set of the status rules file, deriving work status from stage phases (else the team one)
+	StatusRules string `yaml:"status_rules"`
+}
+
+/**
+ * Is owner, by name or email (case insensitive)
+ */
+func (meta MetaDefinition) HasOwner(nameOrEmail string) bool {
+	return meta.HasOwnerWithRole(nameOrEmail, nil)
+}
+
+/**
+ * Is owner, by name or email (case insensitive), with one of the roles (any role if empty)
+ */
+func (meta MetaDefinition) HasOwnerWithRole(nameOrEmail string, roles []string) bool {
+	for _, owner := range meta.OwnersByRole(roles) {
+		if strings.EqualFold(owner.Email, nameOrEmail) || strings.EqualFold(owner.Name, nameOrEmail) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * Owners having one of the roles, all owners without role.
+ */
+func (meta MetaDefinition) OwnersByRole(roles []string) []MetaOwnerDefinition {
+	if len(roles) == 0 {
+		return meta.Owners
+	}
+
+	var ret []MetaOwnerDefinition
+
+	for _, owner := range meta.Owners {
+		for _, role := range roles {
+			if strings.EqualFold(owner.Role, role) {
+				ret = append(ret, owner)
+				break
+			}
+		}
+	}
+
+	return ret
 }
 
 /**
@@ -56,81 +125,145 @@ type ReportingDefinition struct {
 type Definition struct {
 	Path, FullName, Name, Team, GitlabLink string
 
+	// Commit SHA the definition was read from (git source only)
+	Revision string
+
+	// URL of the source the definition was read from
+	Source string
+
+	// Named captures of the path pattern, like "domain"
+	PathLabels map[string]string `yaml:"-"`
+
+	// Schema & YAML errors, the definition is skipped when not empty
+	Errors []ValidationError `yaml:"-"`
+
+	// Ignored keys looking like typos of known ones, the definition is still used
+	Warnings []ValidationError `yaml:"-"`
+
 	Jobs map[string]JobDefinition
 
+	// Days a run is expected, default to every day
+	Schedule *ScheduleDefinition
+
 	Meta MetaDefinition
 
 	Reporting ReportingDefinition
 }
 
 type Repository struct {
-	URL, Path       string
+	URLs            []string
+	settings        *cli.EnvSettings
 	linksRepository links.Repository
+
+	// Built once, to share git mirrors & definitions cache between calls
+	mutex   sync.Mutex
+	sources []Source
 }
 
 func NewRepository(settings *cli.EnvSettings) *Repository {
 	return &Repository{
-		URL:             settings.PipelinesURL,
-		Path:            settings.PipelinesPath,
+		URLs:            settings.PipelinesURLs,
+		settings:        settings,
 		linksRepository: links.Load(settings.FrontURLPath),
 	}
 }
 
 /**
- * Find pipeline definitions, from S3 service.
+ * Find pipeline definitions, from all sources, matching the filter.
  */
 func (s *Repository) FindDefinitions(filter utils.Filter) ([]Definition, error) {
+	definitions, err := s.FindAllDefinitions()
 
-	var client repository
+	if err != nil {
+		return nil, err
+	}
 
-	if len(s.URL) == 0 {
-		client = &RepositoryGit{
-			path:            s.Path,
-			linksRepository: s.linksRepository,
-		}
-	} else if strings.HasPrefix(s.URL, "s3://") {
-		client = &RepositoryS3{
-			bucket:          s.Path,
-			linksRepository: s.linksRepository,
-		}
-	} else {
-		file, err := ioutil.TempDir("/tmp", "tintin")
+	return s.FilterDefinitions(definitions, filter)
+}
+
+/**
+ * Find all pipeline definitions, from all sources, including disabled & invalid ones.
+ */
+func (s *Repository) FindAllDefinitions() ([]Definition, error) {
+	sources, err := s.getSources()
+
+	if err != nil {
+		return nil, err
+	}
+
+	bySource := make([][]Definition, len(sources))
+
+	for i, source := range sources {
+		definitions, err := source.Definitions()
 
 		if err != nil {
-			logrus.Fatal(err)
+			return nil, fmt.Errorf("%s: %w", source.URL(), err)
 		}
 
-		client = &RepositoryGit{
-			URL:             s.URL,
-			path:            s.Path,
-			workingDir:      file,
-			linksRepository: s.linksRepository,
-		}
+		logrus.Debugf("Found %d pipelines in %s", len(definitions), source.URL())
+
+		bySource[i] = definitions
 	}
 
-	definitions, err := client.getDefinitions()
+	definitions, err := mergeDefinitions(bySource, s.settings.PipelinesConflict)
 
 	logrus.Infof("Found %d pipelines", len(definitions))
 
-	definitions = s.filterDefinitions(definitions, filter)
+	return definitions, err
+}
 
-	logrus.Infof("After filter: %d pipelines", len(definitions))
+func (s *Repository) getSources() ([]Source, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	return definitions, err
+	if s.sources != nil {
+		return s.sources, nil
+	}
+
+	for _, pattern := range s.settings.PipelinesExclude {
+		if _, err := myglob.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	urls := s.URLs
+
+	// No URL => local PipelinesPath directory
+	if len(urls) == 0 {
+		urls = []string{""}
+	}
+
+	var sources []Source
+
+	for _, u := range urls {
+		source, err := NewSource(u, s.settings, s.linksRepository)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	s.sources = sources
+
+	return s.sources, nil
 }
 
-func (s *Repository) filterDefinitions(definitions []Definition, filter utils.Filter) []Definition {
-	var (
-		ret               []Definition
-		filterPipelineReg *regexp.Regexp
-	)
+/**
+ * Definitions matching the filter, among found ones: enabled ones of the pipeline selector, team & owner.
+ */
+func (s *Repository) FilterDefinitions(definitions []Definition, filter utils.Filter) ([]Definition, error) {
+	var ret []Definition
 
-	if len(filter.Pipelines) > 0 && filter.Pipelines != "*" {
-		filterPipelineReg = regexp.MustCompile(filter.Pipelines)
+	selector, err := NewSelector(filter.Pipelines)
+
+	if err != nil {
+		return nil, err
 	}
 
 	for _, definition := range definitions {
-		if filterPipelineReg != nil && !filterPipelineReg.Match([]byte(definition.Path)) {
+		if !selector.Match(definition.FullName) {
 			continue
 		}
 
@@ -138,6 +271,10 @@ func (s *Repository) filterDefinitions(definitions []Definition, filter utils.Fi
 			continue
 		}
 
+		if len(filter.Owner) > 0 && !definition.Meta.HasOwner(filter.Owner) {
+			continue
+		}
+
 		if !definition.Reporting.Enabled {
 			continue
 		}
@@ -145,11 +282,57 @@ func (s *Repository) filterDefinitions(definitions []Definition, filter utils.Fi
 		ret = append(ret, definition)
 	}
 
-	return ret
+	logrus.Infof("After filter: %d pipelines", len(ret))
+
+	return ret, nil
+}
+
+/**
+ * Excludes of a source kind ("s3", "file" or "git"): only kinds of PipelinesExcludeSources (S3 if not set) exclude pipelines.
+ */
+func sourceExcludes(settings *cli.EnvSettings, kind string) []string {
+	kinds := settings.PipelinesExcludeSources
+
+	if len(kinds) == 0 {
+		kinds = []string{SourceKindS3}
+	}
+
+	for _, k := range kinds {
+		if k == kind {
+			return settings.PipelinesExclude
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Is pipeline excluded, by its full name
+ */
+func isExcluded(excludes []string, fullName string) bool {
+	for _, pattern := range excludes {
+		if match, _ := myglob.Match(pattern, fullName); match {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (s *Repository) GetStorageStatus() string {
-	return "ok"
+	sources, err := s.getSources()
+
+	if err != nil {
+		return "nop: " + err.Error()
+	}
+
+	var statuses []string
+
+	for _, source := range sources {
+		statuses = append(statuses, source.URL()+": "+source.Status())
+	}
+
+	return strings.Join(statuses, ", ")
 }
 
 /**
@@ -159,6 +342,13 @@ func (stage StageDefinition) IsEnabled() bool {
 	return stage.Enabled != "false"
 }
 
+/**
+ * Has stage expectations on post-check value
+ */
+func (stage StageDefinition) HasExpectations() bool {
+	return stage.MinValue != nil || stage.MaxValue != nil || stage.AllowEmpty
+}
+
 /**
  * Is output stage
  */
@@ -174,29 +364,78 @@ func (stage StageDefinition) IsOutputStage() bool {
 }
 
 /**
- * Read content
+ * Read content: expand templates, validate it against the schema, then decode it.
+ * Errors are kept on the definition, to skip it and list it in reports.
  */
-func (def *Definition) parsePipeline(pipelineContent []byte) {
-	err := yaml.Unmarshal(pipelineContent, def)
+func (def *Definition) parsePipeline(pipelineContent []byte, read SourceReader) {
+	expanded, warnings, errs := expandDocument(def.Path, pipelineContent, read)
+
+	def.Errors = errs
+	def.Warnings = warnings
+
+	for _, warning := range warnings {
+		logrus.Warn(warning.Error())
+	}
+
+	if len(def.Errors) > 0 {
+		return
+	}
+
+	if err := yaml.Unmarshal(expanded, def); err != nil {
+		def.Errors = append(def.Errors, ValidationError{File: def.Path, Message: err.Error()})
+		return
+	}
+
+	// Explicit meta.team wins over the path
+	if len(def.Meta.Team) > 0 {
+		def.Team = def.Meta.Team
+	}
+
+	def.validateSchedule(def.Schedule, "schedule")
 
 	for jobName, job := range def.Jobs {
+		def.validateSchedule(job.Schedule, "jobs."+jobName+".schedule")
+
 		job.Name = jobName
-		if job.Contexts == nil || len(job.Contexts) == 0 {
-			job.Contexts = make(map[string]JobContextDefinition)
-			job.Contexts["_default_"] = JobContextDefinition{"_default_", ContextTypeDefault}
-		} else {
-			for contextName, context := range job.Contexts {
-				context.Name = contextName
-				context.Type = ContextTypeSet
-
-				job.Contexts[contextName] = context
-			}
-		}
+
+		def.resolveContexts(&job, read)
 
 		def.Jobs[jobName] = job
 	}
+}
+
+func (def *Definition) validateSchedule(schedule *ScheduleDefinition, path string) {
+	if schedule == nil {
+		return
+	}
 
-	check(err)
+	if len(schedule.Cron) > 0 {
+		if _, err := parseCron(schedule.Cron); err != nil {
+			def.Errors = append(def.Errors, ValidationError{File: def.Path, Path: path + ".cron", Message: err.Error()})
+		}
+	}
+
+	if _, err := parseWeekdays(schedule.Weekdays); err != nil {
+		def.Errors = append(def.Errors, ValidationError{File: def.Path, Path: path + ".weekdays", Message: err.Error()})
+	}
+}
+
+/**
+ * Schedule of the job: its own, or the pipeline one.
+ */
+func (def Definition) JobSchedule(job JobDefinition) *ScheduleDefinition {
+	if job.Schedule != nil {
+		return job.Schedule
+	}
+
+	return def.Schedule
+}
+
+/**
+ * Is definition valid (schema & YAML)
+ */
+func (def Definition) IsValid() bool {
+	return len(def.Errors) == 0
 }
 
 func defaultPipelineDefinition(path string, fullName string, name string, team string, gitlabLink string) Definition {
@@ -215,9 +454,3 @@ func defaultPipelineDefinition(path string, fullName string, name string, team s
 		},
 	}
 }
-
-func check(e error) {
-	if e != nil {
-		panic(e)
-	}
-}
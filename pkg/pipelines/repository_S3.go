@@ -3,6 +3,7 @@ package pipelines
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,13 +11,20 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/datatok/tintin/pkg/utils/cli"
 	"github.com/datatok/tintin/pkg/utils/links"
 	"github.com/sirupsen/logrus"
 )
 
+func init() {
+	RegisterSource("s3", newRepositoryS3)
+}
+
 type RepositoryS3 struct {
 	client          *s3.S3
-	bucket          string
+	bucket, prefix  string
+	excludes        []string
+	naming          *pathNaming
 	linksRepository links.Repository
 }
 
@@ -30,14 +38,60 @@ func getS3Client() *s3.S3 {
 	return s3.New(mySession)
 }
 
-func (s *RepositoryS3) GetStorageStatus() string {
-	svc := getS3Client()
+func newRepositoryS3(u *url.URL, settings *cli.EnvSettings, linksRepository links.Repository) (Source, error) {
+	bucket, prefix, err := parseS3URL(u)
 
-	keyPrefix := "datahub/develop/pipelines"
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := svc.ListObjectsV2(&s3.ListObjectsV2Input{
-		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(keyPrefix),
+	naming, err := newPathNaming(settings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepositoryS3{
+		client:          getS3Client(),
+		bucket:          bucket,
+		prefix:          prefix,
+		excludes:        sourceExcludes(settings, SourceKindS3),
+		naming:          naming,
+		linksRepository: linksRepository,
+	}, nil
+}
+
+/**
+ * Parse "s3://bucket/prefix" URL.
+ */
+func parseS3URL(u *url.URL) (string, string, error) {
+	if u.Scheme != "s3" || len(u.Host) == 0 {
+		return "", "", fmt.Errorf("invalid S3 URL %q, expected s3://bucket/prefix", u)
+	}
+
+	return u.Host, strings.Trim(u.Path, "/"), nil
+}
+
+func (s *RepositoryS3) URL() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+/**
+ * Prefix of listed keys, ending with "/": "pipelines" must not list "pipelines-old/" keys.
+ */
+func (s *RepositoryS3) listPrefix() string {
+	if len(s.prefix) == 0 {
+		return ""
+	}
+
+	return s.prefix + "/"
+}
+
+func (s *RepositoryS3) Status() string {
+	_, err := s.client.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(s.listPrefix()),
+		MaxKeys: aws.Int64(1),
 	})
 
 	if err == nil {
@@ -50,60 +104,92 @@ func (s *RepositoryS3) GetStorageStatus() string {
 /**
  * Find pipeline definitions, from S3 service.
  */
-func (s *RepositoryS3) getDefinitions() ([]Definition, error) {
-	keyPrefix := "datahub/develop/pipelines"
-
-	svc := getS3Client()
+func (s *RepositoryS3) Definitions() ([]Definition, error) {
+	var (
+		ret  []Definition
+		keys []string
+	)
 
-	logrus.WithField("URL", s.bucket).Infof("Searching pipelines")
+	logrus.WithField("bucket", s.bucket).WithField("prefix", s.prefix).Infof("Searching pipelines")
 
-	resp, err := svc.ListObjectsV2(&s3.ListObjectsV2Input{
+	err := s.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
 		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(keyPrefix),
+		Prefix: aws.String(s.listPrefix()),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, item := range page.Contents {
+			keys = append(keys, *item.Key)
+		}
+
+		return true
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("Unable to list items in URL %q, %v", s.bucket, err)
+		return nil, fmt.Errorf("Unable to list items in s3://%s/%s, %v", s.bucket, s.prefix, err)
 	}
 
-	var (
-		ret []Definition
-	)
-
-	for _, item := range resp.Contents {
-		value := *item.Key
-		fullName := strings.Trim(filepath.Dir(strings.Replace(value, keyPrefix, "", 1)), "/")
+	logrus.Debugf("Found %d S3 objects", len(keys))
 
-		if !strings.Contains(fullName, "/dev") &&
-			(strings.HasSuffix(value, ".yml") || strings.HasSuffix(value, ".yaml")) {
-			pp := strings.Replace(value, keyPrefix, "", 1)
-			team := "steam"
-			name := fullName
+	for _, value := range keys {
+		pp := strings.TrimPrefix(value, s.listPrefix())
+		fullName := strings.Trim(filepath.Dir(pp), "/")
 
-			if strings.Contains(fullName, "/") {
-				team = strings.Split(fullName, "/")[0]
-				name = strings.Trim(strings.TrimLeft(fullName, team), "/")
-			}
+		if !isDefinitionFile(value) || isExcluded(s.excludes, fullName) ||
+			strings.HasPrefix(strings.TrimPrefix(pp, "/"), TemplatesDir+"/") {
+			continue
+		}
 
-			def := defaultPipelineDefinition(value, fullName, name, team, s.linksRepository.Generate(GitlabURL, map[string]string{"uri": pp}))
+		def := s.naming.definition(value, fullName, s.linksRepository.Generate(GitlabURL, map[string]string{"uri": pp}))
+		def.Source = s.URL()
 
-			rawObject, err := svc.GetObject(
-				&s3.GetObjectInput{
-					Bucket: aws.String(s.bucket),
-					Key:    aws.String(value),
-				})
-			if err != nil {
-				return nil, fmt.Errorf("Unable to download item %q, %v", item, err)
-			}
+		rawObject, err := s.client.GetObject(
+			&s3.GetObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    aws.String(value),
+			})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to download item %q, %v", value, err)
+		}
 
-			buf := new(bytes.Buffer)
-			buf.ReadFrom(rawObject.Body)
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(rawObject.Body)
+		rawObject.Body.Close()
 
-			def.parsePipeline(buf.Bytes())
+		def.parsePipeline(buf.Bytes(), s.readFile)
 
-			ret = append(ret, def)
-		}
+		ret = append(ret, def)
 	}
 
 	return ret, nil
 }
+
+/**
+ * Read file (templates, contexts files...), under the prefix.
+ */
+func (s *RepositoryS3) readFile(p string) (string, []byte, error) {
+	cleaned, err := cleanSourcePath(p)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := strings.TrimPrefix(s.prefix+"/"+cleaned, "/")
+
+	rawObject, err := s.client.GetObject(
+		&s3.GetObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		})
+	if err != nil {
+		return "", nil, err
+	}
+
+	defer rawObject.Body.Close()
+
+	buf := new(bytes.Buffer)
+
+	if _, err := buf.ReadFrom(rawObject.Body); err != nil {
+		return "", nil, err
+	}
+
+	return key, buf.Bytes(), nil
+}
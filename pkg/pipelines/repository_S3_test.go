@@ -0,0 +1,149 @@
+package pipelines
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/links"
+)
+
+// fakeS3 is a minimal S3 stand-in (path style), serving 2 keys per listing page.
+type fakeS3 struct {
+	bucket  string
+	objects map[string]string
+	lists   int
+}
+
+type fakeS3ListResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string
+	Prefix                string
+	KeyCount              int
+	IsTruncated           bool
+	NextContinuationToken string `xml:",omitempty"`
+	Contents              []struct {
+		Key string
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/"+f.bucket)
+
+	if len(path) > 1 {
+		content, ok := f.objects[strings.TrimPrefix(path, "/")]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(content))
+		return
+	}
+
+	f.lists++
+
+	var keys []string
+
+	prefix := r.URL.Query().Get("prefix")
+
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+	end := start + 2
+
+	result := fakeS3ListResult{Name: f.bucket, Prefix: prefix}
+
+	if end < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	} else {
+		end = len(keys)
+	}
+
+	for _, key := range keys[start:end] {
+		result.Contents = append(result.Contents, struct{ Key string }{key})
+	}
+
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func TestRepositoryS3_getDefinitions(t *testing.T) {
+	pipeline := "jobs:\n  a:\n    stages:\n      output:\n        kind: output\n"
+
+	fake := &fakeS3{
+		bucket: "datahub",
+		objects: map[string]string{
+			"develop/pipelines/team_a/archivr/partners.yaml":  "partners:\n  - name: acme\n",
+			"develop/pipelines/team_a/archivr/pipeline.yaml":  pipeline,
+			"develop/pipelines/team_a/conso/pipeline.yml":     pipeline,
+			"develop/pipelines/team_a/conso/README.md":        "not a pipeline",
+			"develop/pipelines/team_b/archivr/pipeline.yaml":  pipeline,
+			"develop/pipelines/team_b/dev/test/pipeline.yaml": pipeline,
+			"develop/pipelines/team_c/sandbox/pipeline.yaml":  pipeline,
+			"develop/pipelines-old/team_a/old/pipeline.yaml":  pipeline,
+			"main/pipelines/team_a/archivr/pipeline.yaml":     pipeline,
+		},
+	}
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := s3.New(session.Must(session.NewSession(&aws.Config{
+		Region:           aws.String("us-east-1"),
+		Endpoint:         aws.String(server.URL),
+		Credentials:      credentials.NewStaticCredentials("key", "secret", ""),
+		S3ForcePathStyle: aws.Bool(true),
+	})))
+
+	source, err := NewSource("s3://datahub/develop/pipelines/", &cli.EnvSettings{
+		PipelinesExclude: []string{"**/dev*/**", "team_c/**"},
+	}, links.Repository{})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	repo := source.(*RepositoryS3)
+	repo.client = client
+
+	definitions, err := repo.Definitions()
+
+	a := assert.New(t)
+
+	if a.NoError(err) {
+		var names []string
+
+		for _, definition := range definitions {
+			names = append(names, definition.FullName)
+		}
+
+		a.Equal([]string{"team_a/archivr", "team_a/conso", "team_b/archivr"}, names)
+		a.Equal(4, fake.lists, "must page through all listings")
+		a.Empty(definitions[0].Errors, "contexts files next to pipeline.yaml must not be parsed as pipelines")
+		a.Equal("s3://datahub/develop/pipelines", definitions[0].Source)
+	}
+
+	a.Equal("ok, S3 connected, URL found", repo.Status())
+}
@@ -0,0 +1,174 @@
+package pipelines
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/links"
+)
+
+func init() {
+	RegisterSource("file", newRepositoryFile)
+}
+
+/**
+ * Pipeline definitions from a local directory.
+ */
+type RepositoryFile struct {
+	url, path       string
+	excludes        []string
+	naming          *pathNaming
+	linksRepository links.Repository
+
+	// Watch mode: definitions loaded once, then reloaded on file changes
+	watch    bool
+	mutex    sync.Mutex
+	watcher  *fileWatcher
+	watchErr error
+	files    map[string]*watchedFile
+}
+
+func newRepositoryFile(u *url.URL, settings *cli.EnvSettings, linksRepository links.Repository) (Source, error) {
+	naming, err := newPathNaming(settings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// "file://./relative/path" is parsed with "." as host, cleaned like walked & watched paths
+	return &RepositoryFile{
+		url:             u.String(),
+		path:            filepath.Clean(u.Host + u.Path),
+		excludes:        sourceExcludes(settings, SourceKindFile),
+		naming:          naming,
+		linksRepository: linksRepository,
+		watch:           settings.PipelinesWatch,
+	}, nil
+}
+
+func (s *RepositoryFile) URL() string {
+	return s.url
+}
+
+func (s *RepositoryFile) Status() string {
+	if _, err := os.Stat(s.path); err != nil {
+		return "nop: " + err.Error()
+	}
+
+	if s.isWatching() {
+		return s.watchStatus()
+	}
+
+	if err := s.watchError(); err != nil {
+		return fmt.Sprintf("ok, not watching (%s), walking pipelines on each call", err)
+	}
+
+	return "ok"
+}
+
+func (s *RepositoryFile) Definitions() ([]Definition, error) {
+	if s.watch {
+		definitions, err := s.watchedDefinitions()
+
+		if err == nil {
+			return definitions, nil
+		}
+
+		logrus.Debugf("unable to watch %s, walking it: %s", s.path, err)
+	}
+
+	return walkDefinitions(s.path, "", s.URL(), s.excludes, s.naming, s.linksRepository)
+}
+
+/**
+ * Find and parse pipeline.yaml files, in a local directory.
+ */
+func walkDefinitions(searchPath, revision, source string, excludes []string, naming *pathNaming, linksRepository links.Repository) ([]Definition, error) {
+	var ret []Definition
+
+	read := dirSourceReader(searchPath)
+
+	for _, candidatePath := range findDefinitionFiles(searchPath) {
+		def, ok := fileDefinition(searchPath, candidatePath, revision, source, excludes, naming, linksRepository)
+
+		if !ok {
+			continue
+		}
+
+		dat, err := os.ReadFile(candidatePath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		def.parsePipeline(dat, read)
+
+		ret = append(ret, def)
+	}
+
+	return ret, nil
+}
+
+func isDefinitionFile(path string) bool {
+	return filepath.Base(path) == "pipeline.yml" || filepath.Base(path) == "pipeline.yaml"
+}
+
+func findDefinitionFiles(searchPath string) []string {
+	var candidatesPath []string
+
+	logrus.Debug("Searching pipeline.yaml files")
+
+	filepath.Walk(searchPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			fmt.Println(err)
+			return nil
+		}
+
+		if info.IsDir() && info.Name() == TemplatesDir {
+			return filepath.SkipDir
+		}
+
+		if !info.IsDir() && isDefinitionFile(path) {
+			candidatesPath = append(candidatesPath, path)
+		}
+
+		return nil
+	})
+
+	return candidatesPath
+}
+
+/**
+ * Build definition of a pipeline.yaml file (not parsed yet), false if excluded.
+ */
+func fileDefinition(searchPath, candidatePath, revision, source string, excludes []string, naming *pathNaming, linksRepository links.Repository) (Definition, bool) {
+	workingDirSanitized := sanitizePath(searchPath)
+	candidatePathSanitized := sanitizePath(candidatePath)
+	pp := strings.Replace(candidatePathSanitized, workingDirSanitized, "", 1)
+	fullName := strings.Trim(filepath.Dir(pp), "/")
+
+	if isExcluded(excludes, fullName) {
+		return Definition{}, false
+	}
+
+	def := naming.definition(candidatePath, fullName, linksRepository.Generate(GitlabURL, map[string]string{"uri": pp, "revision": revision}))
+	def.Revision = revision
+	def.Source = source
+
+	return def, true
+}
+
+func sanitizePath(p string) string {
+	p = strings.TrimLeft(p, "./")
+	p = strings.TrimLeft(p, "../")
+
+	return p
+}
@@ -0,0 +1,264 @@
+package pipelines
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+/**
+ * A watched pipeline.yaml file: definition in service, and last reload errors.
+ */
+type watchedFile struct {
+	hash       [sha1.Size]byte
+	definition Definition
+	errors     []ValidationError
+}
+
+func (s *RepositoryFile) isWatching() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.watcher != nil
+}
+
+/**
+ * Why watching could not start (nil if not tried, or watching).
+ */
+func (s *RepositoryFile) watchError() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.watchErr
+}
+
+/**
+ * Load definitions once & start watcher, then serve definitions from memory.
+ */
+func (s *RepositoryFile) watchedDefinitions() ([]Definition, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.watchErr != nil {
+		return nil, s.watchErr
+	}
+
+	if s.watcher == nil {
+		watcher, err := newFileWatcher()
+
+		if err == nil {
+			if err = watcher.AddRecursive(s.path); err != nil {
+				watcher.Close()
+			}
+		}
+
+		if err != nil {
+			logrus.Warnf("unable to watch %s, walking it on each call: %s", s.path, err)
+
+			s.watchErr = err
+
+			return nil, err
+		}
+
+		s.watcher = watcher
+		s.files = make(map[string]*watchedFile)
+		s.rescan(s.path)
+
+		logrus.Infof("watching %d pipelines in %s", len(s.files), s.path)
+
+		go s.watchLoop(watcher)
+	}
+
+	var paths []string
+
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	ret := make([]Definition, 0, len(paths))
+
+	for _, path := range paths {
+		ret = append(ret, s.files[path].definition)
+	}
+
+	return ret, nil
+}
+
+/**
+ * Stop watching, definitions are walked again on next call.
+ */
+func (s *RepositoryFile) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.watcher != nil {
+		s.watcher.Close()
+		s.watcher = nil
+	}
+}
+
+func (s *RepositoryFile) watchLoop(watcher *fileWatcher) {
+	for paths := range watcher.Events {
+		s.mutex.Lock()
+
+		for _, path := range paths {
+			info, err := os.Lstat(path)
+			isDir := err == nil && info.IsDir()
+
+			// New directory, or directory / symlink swap (like ConfigMap "..data"): watch it
+			if isDir {
+				if err := watcher.AddRecursive(path); err != nil {
+					logrus.Warnf("unable to watch %s: %s", path, err)
+				}
+			}
+
+			switch {
+			// Template change: reload all definitions, they may extend it
+			case s.isTemplate(path):
+				s.forget(s.path)
+				s.rescan(s.path)
+
+			case isDefinitionFile(path):
+				s.reload(path)
+
+			default:
+				// Other file, like a contexts file: reload definitions next to it
+				if !isDir {
+					s.forget(filepath.Dir(path))
+				}
+
+				s.rescan(filepath.Dir(path))
+			}
+		}
+
+		s.mutex.Unlock()
+	}
+}
+
+/**
+ * Forget content of files under dir, to reload them on next rescan.
+ */
+func (s *RepositoryFile) forget(dir string) {
+	for path, file := range s.files {
+		if isInDir(path, dir) {
+			file.hash = [sha1.Size]byte{}
+		}
+	}
+}
+
+/**
+ * Is path dir itself or under it, paths being cleaned (like "." and "team_a/pipeline.yaml").
+ */
+func isInDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (s *RepositoryFile) isTemplate(path string) bool {
+	rel, err := filepath.Rel(s.path, path)
+
+	return err == nil && (rel == TemplatesDir || strings.HasPrefix(rel, TemplatesDir+string(filepath.Separator)))
+}
+
+/**
+ * Reload definitions of a directory, forget the vanished ones.
+ */
+func (s *RepositoryFile) rescan(dir string) {
+	found := make(map[string]bool)
+
+	// Removed directory: only forget its definitions
+	if _, err := os.Stat(dir); err == nil {
+		for _, path := range findDefinitionFiles(dir) {
+			found[path] = true
+
+			s.reload(path)
+		}
+	}
+
+	for path := range s.files {
+		if isInDir(path, dir) && !found[path] {
+			logrus.Infof("pipeline %s removed", path)
+
+			delete(s.files, path)
+		}
+	}
+}
+
+/**
+ * Re-parse file if its content has changed. An invalid new version does not replace
+ * a valid one: its errors are kept for status.
+ */
+func (s *RepositoryFile) reload(path string) {
+	dat, err := os.ReadFile(path)
+
+	if err != nil {
+		if _, ok := s.files[path]; ok {
+			logrus.Infof("pipeline %s removed", path)
+
+			delete(s.files, path)
+		}
+
+		return
+	}
+
+	hash := sha1.Sum(dat)
+	previous, ok := s.files[path]
+
+	if ok && previous.hash == hash {
+		return
+	}
+
+	def, ok := fileDefinition(s.path, path, "", s.URL(), s.excludes, s.naming, s.linksRepository)
+
+	if !ok {
+		return
+	}
+
+	def.parsePipeline(dat, dirSourceReader(s.path))
+
+	if !def.IsValid() && previous != nil && previous.definition.IsValid() {
+		logrus.Warnf("pipeline %s is invalid, keeping last valid version", path)
+
+		previous.hash = hash
+		previous.errors = def.Errors
+
+		return
+	}
+
+	logrus.Infof("pipeline %s loaded", path)
+
+	s.files[path] = &watchedFile{
+		hash:       hash,
+		definition: def,
+		errors:     def.Errors,
+	}
+}
+
+func (s *RepositoryFile) watchStatus() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var errors []string
+
+	for _, file := range s.files {
+		for _, err := range file.errors {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if len(errors) == 0 {
+		return fmt.Sprintf("ok, watching %d pipelines", len(s.files))
+	}
+
+	sort.Strings(errors)
+
+	return fmt.Sprintf("ok, watching %d pipelines, %d errors: %s", len(s.files), len(errors), strings.Join(errors, "; "))
+}
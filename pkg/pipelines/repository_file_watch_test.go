@@ -0,0 +1,175 @@
+package pipelines
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/links"
+)
+
+func TestRepositoryFile_Watch(t *testing.T) {
+	t.Run("absolute root", func(t *testing.T) {
+		dir := t.TempDir()
+
+		testRepositoryFileWatch(t, dir, "file://"+dir)
+	})
+
+	// Relative roots: watched paths are cleaned like walked ones
+	for _, root := range []string{".", "./pipelines"} {
+		t.Run("relative root "+root, func(t *testing.T) {
+			dir := t.TempDir()
+			wd, err := os.Getwd()
+
+			require.NoError(t, err)
+			require.NoError(t, os.Chdir(dir))
+
+			defer func() {
+				require.NoError(t, os.Chdir(wd))
+			}()
+
+			testRepositoryFileWatch(t, filepath.Join(dir, root), "file://"+root)
+		})
+	}
+}
+
+func testRepositoryFileWatch(t *testing.T, dir, root string) {
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+
+	write("team_a/archivr/pipeline.yaml", "jobs:\n  archivr:\n    stages: {}\n")
+
+	u, _ := url.Parse(root)
+
+	created, err := newRepositoryFile(u, &cli.EnvSettings{PipelinesWatch: true}, links.Repository{})
+
+	require.NoError(t, err)
+
+	source := created.(*RepositoryFile)
+	defer source.Close()
+
+	// Wait for the watcher to catch up
+	eventually := func(condition func(definitions []Definition) bool, msg string) {
+		assert.Eventually(t, func() bool {
+			definitions, err := source.Definitions()
+
+			return err == nil && condition(definitions)
+		}, 5*time.Second, 20*time.Millisecond, msg)
+	}
+
+	definitions, err := source.Definitions()
+
+	if assert.NoError(t, err) && assert.Len(t, definitions, 1) {
+		assert.Contains(t, definitions[0].Jobs, "archivr")
+	}
+
+	assert.Equal(t, "ok, watching 1 pipelines", source.Status())
+
+	t.Run("modified", func(t *testing.T) {
+		write("team_a/archivr/pipeline.yaml", "jobs:\n  archivr_v2:\n    stages: {}\n")
+
+		eventually(func(definitions []Definition) bool {
+			_, ok := definitions[0].Jobs["archivr_v2"]
+			return ok
+		}, "modified pipeline is reloaded")
+	})
+
+	t.Run("invalid keeps last valid version", func(t *testing.T) {
+		write("team_a/archivr/pipeline.yaml", "jobs:\n  archivr_v3:\n    stagess: {}\n")
+
+		assert.Eventually(t, func() bool {
+			return source.Status() != "ok, watching 1 pipelines"
+		}, 5*time.Second, 20*time.Millisecond)
+
+		assert.Contains(t, source.Status(), `unknown key "stagess"`)
+
+		definitions, _ := source.Definitions()
+
+		if assert.Len(t, definitions, 1) {
+			assert.True(t, definitions[0].IsValid())
+			assert.Contains(t, definitions[0].Jobs, "archivr_v2")
+		}
+	})
+
+	t.Run("new directory", func(t *testing.T) {
+		write("team_b/conso/daily/pipeline.yml", "jobs:\n  conso:\n    stages: {}\n")
+
+		eventually(func(definitions []Definition) bool {
+			return len(definitions) == 2 && definitions[1].FullName == "team_b/conso/daily"
+		}, "new pipeline is loaded")
+	})
+
+	t.Run("contexts file", func(t *testing.T) {
+		write("team_b/conso/daily/countries.yaml", "[fr]\n")
+		write("team_b/conso/daily/pipeline.yml", "jobs:\n  conso:\n    contexts_file: countries.yaml\n    stages: {}\n")
+
+		eventually(func(definitions []Definition) bool {
+			return len(definitions) == 2 && len(definitions[1].Jobs["conso"].Contexts) == 1
+		}, "contexts are loaded")
+
+		write("team_b/conso/daily/countries.yaml", "[fr, de]\n")
+
+		eventually(func(definitions []Definition) bool {
+			return len(definitions) == 2 && len(definitions[1].Jobs["conso"].Contexts) == 2
+		}, "pipeline is reloaded with its contexts file")
+	})
+
+	t.Run("template", func(t *testing.T) {
+		write("_templates/daily.yaml", "schedule:\n  weekdays: [mon]\n")
+		write("team_b/conso/daily/pipeline.yml", "jobs:\n  conso:\n    extends: daily\n    contexts_file: countries.yaml\n    stages: {}\n")
+
+		weekdays := func(definitions []Definition) []string {
+			if len(definitions) != 2 || definitions[1].Jobs["conso"].Schedule == nil {
+				return nil
+			}
+
+			return definitions[1].Jobs["conso"].Schedule.Weekdays
+		}
+
+		eventually(func(definitions []Definition) bool {
+			return assert.ObjectsAreEqual([]string{"mon"}, weekdays(definitions))
+		}, "pipeline extending the template is loaded")
+
+		write("_templates/daily.yaml", "schedule:\n  weekdays: [tue]\n")
+
+		eventually(func(definitions []Definition) bool {
+			return assert.ObjectsAreEqual([]string{"tue"}, weekdays(definitions))
+		}, "pipeline is reloaded with its changed template")
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "team_a")))
+
+		eventually(func(definitions []Definition) bool {
+			return len(definitions) == 1 && definitions[0].FullName == "team_b/conso/daily"
+		}, "removed pipeline is forgotten")
+	})
+}
+
+func TestRepositoryFile_NotWatching(t *testing.T) {
+	u, _ := url.Parse("file://./testdata/pipelines")
+
+	created, err := newRepositoryFile(u, &cli.EnvSettings{PipelinesWatch: true}, links.Repository{})
+
+	require.NoError(t, err)
+
+	source := created.(*RepositoryFile)
+	source.watchErr = errors.New("too many open files")
+
+	definitions, err := source.Definitions()
+
+	if assert.NoError(t, err) {
+		assert.Len(t, definitions, 3)
+	}
+
+	assert.Equal(t, "ok, not watching (too many open files), walking pipelines on each call", source.Status())
+}
@@ -1,100 +1,375 @@
 package pipelines
 
 import (
+	"crypto/sha1"
 	"fmt"
+	"github.com/datatok/tintin/pkg/utils/cli"
 	"github.com/datatok/tintin/pkg/utils/links"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/sirupsen/logrus"
-	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
-type RepositoryGit struct {
-	URL, path, workingDir string
-	linksRepository       links.Repository
+func init() {
+	for _, scheme := range []string{"git+https", "git+http", "git+ssh", "git+file", "git+legacy"} {
+		RegisterSource(scheme, newRepositoryGit)
+	}
 }
 
-func (s *RepositoryGit) getDefinitions() ([]Definition, error) {
+/**
+ * Pipeline definitions from a git repository, kept in a local mirror.
+ */
+type RepositoryGit struct {
+	remote, path, workingDir, ref string
+	auth                          transport.AuthMethod
+	fetchInterval                 time.Duration
+	excludes                      []string
+	naming                        *pathNaming
+	linksRepository               links.Repository
 
-	var (
-		ret            []Definition
-		candidatesPath []string
-	)
+	// Local mirror state, shared by concurrent calls
+	mutex       sync.Mutex
+	repo        *git.Repository
+	lastFetch   time.Time
+	revision    string
+	definitions map[string][]Definition
+}
 
-	if len(s.URL) > 0 {
-		logrus.Debugf("Cloning repo into %s", s.workingDir)
+/**
+ * Build git source from "git+https://host/repo.git#ref=develop&path=pipelines" URL,
+ * ref & path default to settings.
+ */
+func newRepositoryGit(u *url.URL, settings *cli.EnvSettings, linksRepository links.Repository) (Source, error) {
+	var remote string
 
-		_, err := git.PlainClone(s.workingDir, false, &git.CloneOptions{
-			URL:             s.URL,
-			InsecureSkipTLS: os.Getenv("GIT_SSL_NO_VERIFY") == "true",
-			Depth:           1,
-			SingleBranch:    true,
-		})
+	ref, path := settings.PipelinesGitRef, settings.PipelinesPath
+
+	if u.Scheme == "git+legacy" {
+		remote = u.Opaque
+	} else {
+		options, err := url.ParseQuery(u.Fragment)
 
 		if err != nil {
-			logrus.Fatal(err)
+			return nil, fmt.Errorf("invalid git URL options %q: %w", u.Fragment, err)
+		}
+
+		if v := options.Get("ref"); len(v) > 0 {
+			ref = v
+		}
+
+		if v := options.Get("path"); len(v) > 0 {
+			path = v
+		}
+
+		remoteURL := *u
+		remoteURL.Scheme = strings.TrimPrefix(u.Scheme, "git+")
+		remoteURL.Fragment = ""
+
+		remote = remoteURL.String()
+	}
+
+	auth, err := gitAuth(settings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	naming, err := newPathNaming(settings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepositoryGit{
+		remote:          remote,
+		path:            path,
+		ref:             ref,
+		auth:            auth,
+		fetchInterval:   settings.PipelinesGitFetchInterval,
+		workingDir:      gitMirrorDir(settings.PipelinesGitCacheDir, remote, ref),
+		excludes:        sourceExcludes(settings, SourceKindGit),
+		naming:          naming,
+		linksRepository: linksRepository,
+	}, nil
+}
+
+/**
+ * Build git auth method from settings: SSH key, SSH agent, token or basic auth.
+ */
+func gitAuth(settings *cli.EnvSettings) (transport.AuthMethod, error) {
+	switch {
+	case len(settings.PipelinesGitSSHKeyPath) > 0:
+		return gitssh.NewPublicKeysFromFile(gitSSHUser(settings), settings.PipelinesGitSSHKeyPath, settings.PipelinesGitSSHKeyPassword)
+	case settings.PipelinesGitSSHAgent:
+		return gitssh.NewSSHAgentAuth(gitSSHUser(settings))
+	case len(settings.PipelinesGitToken) > 0:
+		username := settings.PipelinesGitUsername
+
+		// GitLab & GitHub accept any non-empty username with a token
+		if len(username) == 0 {
+			username = "oauth2"
 		}
+
+		return &githttp.BasicAuth{Username: username, Password: settings.PipelinesGitToken}, nil
+	case len(settings.PipelinesGitUsername) > 0:
+		return &githttp.BasicAuth{Username: settings.PipelinesGitUsername, Password: settings.PipelinesGitPassword}, nil
 	}
 
-	searchPath := filepath.Join(s.workingDir, s.path)
-	workingDirSanitized := sanitizePath(searchPath)
+	return nil, nil
+}
 
-	logrus.Debug("Searching pipeline.yaml files")
+/**
+ * Mirror directory, one per URL & ref.
+ */
+func gitMirrorDir(cacheDir, remote, ref string) string {
+	key := fmt.Sprintf("%x", sha1.Sum([]byte(remote+"#"+ref)))
 
-	filepath.Walk(searchPath, func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			fmt.Println(err)
+	return filepath.Join(cacheDir, key[:16])
+}
+
+func gitSSHUser(settings *cli.EnvSettings) string {
+	if len(settings.PipelinesGitUsername) > 0 {
+		return settings.PipelinesGitUsername
+	}
+
+	return "git"
+}
+
+/**
+ * Open the local mirror (clone it if missing), fetch if the fetch interval is elapsed,
+ * and checkout the configured ref.
+ */
+func (s *RepositoryGit) sync() error {
+	cloned := false
+
+	if s.repo == nil {
+		var err error
+
+		if cloned, err = s.open(); err != nil {
+			return err
+		}
+	}
+
+	if !cloned {
+		if time.Since(s.lastFetch) < s.fetchInterval {
 			return nil
 		}
 
-		if !info.IsDir() && (filepath.Base(path) == "pipeline.yml" || filepath.Base(path) == "pipeline.yaml") {
-			candidatesPath = append(candidatesPath, path)
+		if err := s.fetch(); err != nil {
+			return err
 		}
+	}
 
-		return nil
-	})
+	s.lastFetch = time.Now()
 
-	for _, candidatePath := range candidatesPath {
-		candidatePathSanitized := sanitizePath(candidatePath)
-		pp := strings.Replace(candidatePathSanitized, workingDirSanitized, "", 1)
-		fullName := strings.Trim(filepath.Dir(strings.Replace(candidatePathSanitized, workingDirSanitized, "", 1)), "/")
-		team := "steam"
-		name := fullName
+	return s.checkout()
+}
 
-		if strings.Contains(fullName, "/") {
-			team = strings.Split(fullName, "/")[0]
-			name = strings.Trim(strings.TrimLeft(fullName, team), "/")
-		}
+/**
+ * Open the existing mirror, or clone it.
+ */
+func (s *RepositoryGit) open() (bool, error) {
+	repo, err := git.PlainOpen(s.workingDir)
+
+	if err == nil {
+		s.repo = repo
 
-		def := defaultPipelineDefinition(candidatePath, fullName, name, team, s.linksRepository.Generate(GitlabURL, map[string]string{"uri": pp}))
+		return false, nil
+	}
 
-		dat, err := os.ReadFile(candidatePath)
+	if err != git.ErrRepositoryNotExists {
+		logrus.Warnf("git mirror %s is broken (%s), cloning again", s.workingDir, err)
 
-		if err != nil {
-			logrus.Fatal(err)
+		if err := os.RemoveAll(s.workingDir); err != nil {
+			return false, err
 		}
+	}
+
+	logrus.Debugf("Cloning repo into %s", s.workingDir)
+
+	repo, err = git.PlainClone(s.workingDir, false, &git.CloneOptions{
+		URL:             s.remote,
+		Auth:            s.auth,
+		NoCheckout:      true,
+		InsecureSkipTLS: gitInsecureSkipTLS(),
+	})
+
+	if err != nil {
+		// Dont keep a half cloned mirror
+		_ = os.RemoveAll(s.workingDir)
+
+		return false, fmt.Errorf("unable to clone %s: %w", s.remote, err)
+	}
+
+	s.repo = repo
+
+	return true, nil
+}
+
+func (s *RepositoryGit) fetch() error {
+	logrus.Debugf("Fetching %s into %s", s.remote, s.workingDir)
+
+	err := s.repo.Fetch(&git.FetchOptions{
+		RemoteName:      git.DefaultRemoteName,
+		RefSpecs:        []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		Auth:            s.auth,
+		Tags:            git.AllTags,
+		Force:           true,
+		InsecureSkipTLS: gitInsecureSkipTLS(),
+	})
+
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("unable to fetch %s: %w", s.remote, err)
+	}
+
+	return nil
+}
+
+/**
+ * Move the worktree to the configured ref (or to the remote default branch).
+ */
+func (s *RepositoryGit) checkout() error {
+	var (
+		hash plumbing.Hash
+		err  error
+	)
+
+	if len(s.ref) > 0 {
+		hash, err = resolveRevision(s.repo, s.ref)
+	} else {
+		hash, err = s.resolveDefaultBranch()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if hash.String() == s.revision {
+		return nil
+	}
 
-		def.parsePipeline(dat)
+	worktree, err := s.repo.Worktree()
 
-		ret = append(ret, def)
+	if err != nil {
+		return err
 	}
 
-	logrus.Debugf("removing %s", s.workingDir)
+	// Stay on the default branch (fast-forward), or detach to the configured ref
+	if len(s.ref) > 0 {
+		err = worktree.Checkout(&git.CheckoutOptions{Hash: hash, Force: true})
+	} else {
+		err = worktree.Reset(&git.ResetOptions{Commit: hash, Mode: git.HardReset})
+	}
 
-	err := os.RemoveAll(s.workingDir)
+	if err != nil {
+		return fmt.Errorf("unable to checkout %s: %w", hash, err)
+	}
+
+	logrus.Infof("pipelines git mirror %s is at %s", s.remote, hash)
+
+	s.revision = hash.String()
+
+	return nil
+}
+
+/**
+ * HEAD of a fresh clone targets the remote default branch, follow its remote tracking ref.
+ */
+func (s *RepositoryGit) resolveDefaultBranch() (plumbing.Hash, error) {
+	head, err := s.repo.Storer.Reference(plumbing.HEAD)
 
 	if err != nil {
-		logrus.Fatal(err)
+		return plumbing.ZeroHash, err
+	}
+
+	if head.Type() != plumbing.SymbolicReference {
+		return head.Hash(), nil
 	}
 
-	return ret, nil
+	return resolveRevision(s.repo, head.Target().Short())
 }
 
-func sanitizePath(p string) string {
-	p = strings.TrimLeft(p, "./")
-	p = strings.TrimLeft(p, "../")
+func gitInsecureSkipTLS() bool {
+	return os.Getenv("GIT_SSL_NO_VERIFY") == "true"
+}
+
+/**
+ * Resolve a branch, tag or commit SHA to a commit hash.
+ */
+func resolveRevision(repo *git.Repository, ref string) (plumbing.Hash, error) {
+	if tagRef, err := repo.Tag(ref); err == nil {
+		// Annotated tag => resolve the tagged commit
+		if tag, err := repo.TagObject(tagRef.Hash()); err == nil {
+			commit, err := tag.Commit()
+
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+
+			return commit.Hash, nil
+		}
+
+		return tagRef.Hash(), nil
+	}
+
+	for _, rev := range []string{"refs/remotes/origin/" + ref, ref} {
+		if hash, err := repo.ResolveRevision(plumbing.Revision(rev)); err == nil {
+			return *hash, nil
+		}
+	}
+
+	return plumbing.ZeroHash, fmt.Errorf("unable to resolve git ref %q", ref)
+}
+
+func (s *RepositoryGit) URL() string {
+	return redactURL(s.remote)
+}
+
+func (s *RepositoryGit) Status() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.revision) == 0 {
+		return "nop: not cloned yet"
+	}
+
+	return fmt.Sprintf("ok, at %s (fetched %s)", s.revision, s.lastFetch.Format(time.RFC3339))
+}
+
+func (s *RepositoryGit) Definitions() ([]Definition, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.sync(); err != nil {
+		// Keep serving the last checkout, if any
+		if len(s.revision) == 0 {
+			return nil, err
+		}
+
+		logrus.Warnf("%s, using revision %s", err, s.revision)
+	}
+
+	// Callers get their own slice, definitions maps are shared read-only (see Source)
+	if definitions, ok := s.definitions[s.revision]; ok {
+		return append([]Definition(nil), definitions...), nil
+	}
+
+	definitions, err := walkDefinitions(filepath.Join(s.workingDir, s.path), s.revision, s.URL(), s.excludes, s.naming, s.linksRepository)
+
+	if err == nil {
+		// Only keep the current revision
+		s.definitions = map[string][]Definition{s.revision: definitions}
+	}
 
-	return p
+	return append([]Definition(nil), definitions...), err
 }
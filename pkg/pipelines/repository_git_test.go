@@ -0,0 +1,192 @@
+package pipelines
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type gitFixture struct {
+	dir      string
+	commits  map[string]string
+	worktree *git.Worktree
+}
+
+// newGitFixture creates a git repository with one pipeline on master,
+// and a second one on the "develop" branch (also tagged "v1").
+func newGitFixture(t *testing.T) *gitFixture {
+	dir := t.TempDir()
+	commits := make(map[string]string)
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	f := &gitFixture{dir: dir, commits: commits, worktree: worktree}
+
+	commits["master"] = f.commit(t, "pipelines/team_a/a/pipeline.yaml", "first")
+
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("develop"), Create: true}))
+
+	commits["develop"] = f.commit(t, "pipelines/team_a/b/pipeline.yaml", "second")
+
+	_, err = repo.CreateTag("v1", plumbing.NewHash(commits["develop"]), &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "tintin", Email: "tintin@localhost", When: time.Now()},
+		Message: "v1",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("master")}))
+
+	return f
+}
+
+// commit adds a pipeline file, on current branch.
+func (f *gitFixture) commit(t *testing.T, file, message string) string {
+	require.NoError(t, os.MkdirAll(filepath.Join(f.dir, filepath.Dir(file)), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(f.dir, file), []byte("jobs:\n  a:\n    stages:\n      output:\n        kind: output\n"), 0644))
+
+	_, err := f.worktree.Add(file)
+	require.NoError(t, err)
+
+	hash, err := f.worktree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: "tintin", Email: "tintin@localhost", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	return hash.String()
+}
+
+func TestRepositoryGit_Ref(t *testing.T) {
+	fixture := newGitFixture(t)
+	dir, commits := fixture.dir, fixture.commits
+
+	tests := []struct {
+		ref, revision string
+		count         int
+	}{
+		{ref: "", revision: commits["master"], count: 1},
+		{ref: "develop", revision: commits["develop"], count: 2},
+		{ref: "v1", revision: commits["develop"], count: 2},
+		{ref: commits["master"], revision: commits["master"], count: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run("ref "+tt.ref, func(t *testing.T) {
+			a := assert.New(t)
+
+			repo := &RepositoryGit{
+				remote:     dir,
+				path:       "pipelines",
+				ref:        tt.ref,
+				workingDir: filepath.Join(t.TempDir(), "clone"),
+				naming:     testPathNaming,
+			}
+
+			definitions, err := repo.Definitions()
+
+			if a.NoError(err) && a.Len(definitions, tt.count) {
+				a.Equal(tt.revision, definitions[0].Revision)
+				a.Equal("team_a", definitions[0].Team)
+			}
+		})
+	}
+
+	t.Run("unknown ref", func(t *testing.T) {
+		repo := &RepositoryGit{
+			remote:     dir,
+			ref:        "nope",
+			workingDir: filepath.Join(t.TempDir(), "clone"),
+			naming:     testPathNaming,
+		}
+
+		_, err := repo.Definitions()
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRepositoryGit_Mirror(t *testing.T) {
+	fixture := newGitFixture(t)
+	workingDir := filepath.Join(t.TempDir(), "mirror")
+
+	t.Run("fetch when interval is elapsed", func(t *testing.T) {
+		a := assert.New(t)
+
+		repo := &RepositoryGit{remote: fixture.dir, path: "pipelines", workingDir: workingDir, naming: testPathNaming}
+
+		definitions, err := repo.Definitions()
+
+		if a.NoError(err) {
+			a.Len(definitions, 1)
+		}
+
+		revision := fixture.commit(t, "pipelines/team_b/c/pipeline.yaml", "third")
+
+		definitions, err = repo.Definitions()
+
+		if a.NoError(err) && a.Len(definitions, 2) {
+			a.Equal(revision, definitions[0].Revision)
+		}
+
+		definitions[0].FullName = "changed"
+
+		cached, err := repo.Definitions()
+
+		if a.NoError(err) && a.Len(cached, 2) {
+			a.NotEqual("changed", cached[0].FullName, "cached definitions are not changed by callers")
+		}
+	})
+
+	t.Run("reuse mirror, and wait for interval", func(t *testing.T) {
+		a := assert.New(t)
+
+		repo := &RepositoryGit{remote: fixture.dir, path: "pipelines", workingDir: workingDir, fetchInterval: time.Hour, naming: testPathNaming}
+
+		definitions, err := repo.Definitions()
+
+		if a.NoError(err) {
+			a.Len(definitions, 2)
+		}
+
+		fixture.commit(t, "pipelines/team_b/d/pipeline.yaml", "fourth")
+
+		definitions, err = repo.Definitions()
+
+		if a.NoError(err) {
+			a.Len(definitions, 2)
+		}
+	})
+
+	t.Run("concurrent calls", func(t *testing.T) {
+		repo := &RepositoryGit{remote: fixture.dir, path: "pipelines", workingDir: workingDir, naming: testPathNaming}
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				definitions, err := repo.Definitions()
+
+				if assert.NoError(t, err) {
+					assert.Len(t, definitions, 3)
+				}
+			}()
+		}
+
+		wg.Wait()
+	})
+}
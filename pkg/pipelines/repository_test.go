@@ -85,7 +85,7 @@ func TestRepository_FindDefinitionsFromLocalDir(t *testing.T) {
 
 		pipelines, _ = repo.FindDefinitions(utils.Filter{
 			Team:      "team_b",
-			Pipelines: "archivr",
+			Pipelines: []string{"*/archivr"},
 		})
 
 		if a.Len(pipelines, 1) {
@@ -116,6 +116,25 @@ func TestRepository_FindDefinitionsFromLocalDir(t *testing.T) {
 	})
 }
 
+func TestRepository_FindDefinitionsByOwner(t *testing.T) {
+	repo := NewRepository(&cli.EnvSettings{
+		PipelinesPath: "./testdata/pipelines",
+	})
+
+	for _, owner := range []string{"alice@localhost", "BOB@localhost", "Alice"} {
+		pipelines, err := repo.FindDefinitions(utils.Filter{Owner: owner})
+
+		if assert.NoError(t, err) && assert.Len(t, pipelines, 1, owner) {
+			assert.Equal(t, "team_a/conso", pipelines[0].FullName)
+			assert.Equal(t, []MetaOwnerDefinition{{"Bob", "bob@localhost", "ops"}}, pipelines[0].Meta.OwnersByRole([]string{"OPS"}))
+		}
+	}
+
+	pipelines, _ := repo.FindDefinitions(utils.Filter{Owner: "carol@localhost"})
+
+	assert.Empty(t, pipelines)
+}
+
 /*
 func TestRepository_FindDefinitions(t *testing.T) {
 	repo := NewRepository(&cli.EnvSettings{
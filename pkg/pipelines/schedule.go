@@ -0,0 +1,338 @@
+package pipelines
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Date format of holidays, in calendars file
+const CalendarDateFormat = "2006-01-02"
+
+/**
+ * When a pipeline (or a job) is expected to run. All declared rules must match,
+ * no schedule means every day.
+ */
+type ScheduleDefinition struct {
+	// Cron expression "minute hour day-of-month month day-of-week", only days are checked
+	Cron string
+
+	// Week days, like "mon" or "monday"
+	Weekdays []string
+
+	// Business-day calendar name, from the calendars file
+	Calendar string
+}
+
+/**
+ * Business-day calendar: working week days and holidays.
+ */
+type Calendar struct {
+	Weekdays []string
+	Holidays []string
+}
+
+type Calendars map[string]Calendar
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+/**
+ * Load calendars YAML file, empty path means no calendar.
+ */
+func LoadCalendars(path string) (Calendars, error) {
+	calendars := make(Calendars)
+
+	if len(path) == 0 {
+		return calendars, nil
+	}
+
+	dat, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(dat, &calendars); err != nil {
+		return nil, fmt.Errorf("invalid calendars file %s: %w", path, err)
+	}
+
+	for name, calendar := range calendars {
+		if _, err := parseWeekdays(calendar.Weekdays); err != nil {
+			return nil, fmt.Errorf("calendar %s: %w", name, err)
+		}
+
+		for _, holiday := range calendar.Holidays {
+			if _, err := time.Parse(CalendarDateFormat, holiday); err != nil {
+				return nil, fmt.Errorf("calendar %s: invalid holiday %q, expected YYYY-MM-DD", name, holiday)
+			}
+		}
+	}
+
+	return calendars, nil
+}
+
+/**
+ * Is a business day: working week day, and not a holiday.
+ */
+func (c Calendar) IsBusinessDay(day time.Time) bool {
+	weekdays, _ := parseWeekdays(c.Weekdays)
+
+	if len(weekdays) > 0 && !weekdays[day.Weekday()] {
+		return false
+	}
+
+	date := day.Format(CalendarDateFormat)
+
+	for _, holiday := range c.Holidays {
+		if holiday == date {
+			return false
+		}
+	}
+
+	return true
+}
+
+/**
+ * Is a run expected on this day.
+ */
+func (s *ScheduleDefinition) Expects(day time.Time, calendars Calendars) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+
+	if len(s.Cron) > 0 {
+		cron, err := parseCron(s.Cron)
+
+		if err != nil || !cron.matches(day) {
+			return false, err
+		}
+	}
+
+	if len(s.Weekdays) > 0 {
+		weekdays, err := parseWeekdays(s.Weekdays)
+
+		if err != nil || !weekdays[day.Weekday()] {
+			return false, err
+		}
+	}
+
+	if len(s.Calendar) > 0 {
+		calendar, ok := calendars[s.Calendar]
+
+		if !ok {
+			return false, fmt.Errorf("unknown calendar %q", s.Calendar)
+		}
+
+		if !calendar.IsBusinessDay(day) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (s *ScheduleDefinition) String() string {
+	var parts []string
+
+	if len(s.Cron) > 0 {
+		parts = append(parts, "cron "+s.Cron)
+	}
+
+	if len(s.Weekdays) > 0 {
+		parts = append(parts, strings.Join(s.Weekdays, ","))
+	}
+
+	if len(s.Calendar) > 0 {
+		parts = append(parts, s.Calendar+" business days")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func parseWeekdays(names []string) (map[time.Weekday]bool, error) {
+	ret := make(map[time.Weekday]bool)
+
+	for _, name := range names {
+		key := strings.ToLower(name)
+
+		if len(key) > 3 {
+			key = key[:3]
+		}
+
+		weekday, ok := weekdayNames[key]
+
+		if !ok {
+			return nil, fmt.Errorf("invalid week day %q", name)
+		}
+
+		ret[weekday] = true
+	}
+
+	return ret, nil
+}
+
+/**
+ * Days of a cron expression "minute hour day-of-month month day-of-week",
+ * minute & hour fields are only validated.
+ */
+type cronDays struct {
+	days, months, weekdays map[int]bool
+	anyDay, anyWeekday     bool
+}
+
+func parseCron(expression string) (*cronDays, error) {
+	fields := strings.Fields(expression)
+
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron %q, expected 5 fields", expression)
+	}
+
+	bounds := []struct {
+		min, max int
+		names    map[string]int
+	}{
+		{0, 59, nil},
+		{0, 23, nil},
+		{1, 31, nil},
+		{1, 12, monthNames},
+		{0, 7, weekdayNumbers()},
+	}
+
+	values := make([]map[int]bool, len(fields))
+
+	for i, field := range fields {
+		v, err := parseCronField(field, bounds[i].min, bounds[i].max, bounds[i].names)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %q: %w", expression, err)
+		}
+
+		values[i] = v
+	}
+
+	// Sunday is 0 or 7
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return &cronDays{
+		days:       values[2],
+		months:     values[3],
+		weekdays:   values[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}, nil
+}
+
+/**
+ * As in cron, when both day-of-month & day-of-week are restricted, any of them matches.
+ */
+func (c *cronDays) matches(day time.Time) bool {
+	if !c.months[int(day.Month())] {
+		return false
+	}
+
+	dayMatch := c.days[day.Day()]
+	weekdayMatch := c.weekdays[int(day.Weekday())]
+
+	if !c.anyDay && !c.anyWeekday {
+		return dayMatch || weekdayMatch
+	}
+
+	return dayMatch && weekdayMatch
+}
+
+func weekdayNumbers() map[string]int {
+	ret := make(map[string]int)
+
+	for name, weekday := range weekdayNames {
+		ret[name] = int(weekday)
+	}
+
+	return ret
+}
+
+/**
+ * Parse cron field: "*", "1,15", "1-5", "*\/2", "mon-fri".
+ */
+func parseCronField(field string, min, max int, names map[string]int) (map[int]bool, error) {
+	ret := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+
+			step = s
+			part = part[:i]
+		}
+
+		from, to := min, max
+
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			f, err := parseCronValue(bounds[0], min, max, names)
+
+			if err != nil {
+				return nil, err
+			}
+
+			from, to = f, f
+
+			if len(bounds) == 2 {
+				if to, err = parseCronValue(bounds[1], min, max, names); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+
+		if from > to {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+
+		for v := from; v <= to; v += step {
+			ret[v] = true
+		}
+	}
+
+	return ret, nil
+}
+
+func parseCronValue(value string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(value)
+
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid value %q, expected %d-%d", value, min, max)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,124 @@
+package pipelines
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleDefinition_Expects(t *testing.T) {
+	calendars := Calendars{
+		"fr": {
+			Weekdays: []string{"mon", "tue", "wed", "thu", "fri"},
+			Holidays: []string{"2021-12-24"},
+		},
+	}
+
+	// Friday 24 & Monday 27 december 2021
+	friday := time.Date(2021, 12, 24, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2021, 12, 27, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		schedule       *ScheduleDefinition
+		friday, monday bool
+	}{
+		{"no schedule", nil, true, true},
+		{"every day", &ScheduleDefinition{Cron: "0 6 * * *"}, true, true},
+		{"cron mondays", &ScheduleDefinition{Cron: "0 6 * * MON"}, false, true},
+		{"cron week", &ScheduleDefinition{Cron: "30 2 * * 1-5"}, true, true},
+		{"cron monthly", &ScheduleDefinition{Cron: "0 0 24 * *"}, true, false},
+		{"cron day of month or week", &ScheduleDefinition{Cron: "0 0 1,15 * mon"}, false, true},
+		{"cron every 3 days", &ScheduleDefinition{Cron: "0 0 */3 * *"}, false, false},
+		{"cron other month", &ScheduleDefinition{Cron: "0 0 * jan-nov *"}, false, false},
+		{"weekdays", &ScheduleDefinition{Weekdays: []string{"Friday"}}, true, false},
+		{"calendar", &ScheduleDefinition{Calendar: "fr"}, false, true},
+		{"all rules", &ScheduleDefinition{Cron: "0 0 * * *", Weekdays: []string{"mon"}, Calendar: "fr"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := tt.schedule.Expects(friday, calendars)
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.friday, expected, "friday")
+			}
+
+			expected, err = tt.schedule.Expects(monday, calendars)
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.monday, expected, "monday")
+			}
+		})
+	}
+
+	_, err := (&ScheduleDefinition{Calendar: "us"}).Expects(friday, calendars)
+
+	assert.EqualError(t, err, `unknown calendar "us"`)
+}
+
+func TestDefinition_parsePipelineSchedule(t *testing.T) {
+	a := assert.New(t)
+
+	def := defaultPipelineDefinition("pipeline.yaml", "team_a/a", "a", "team_a", "")
+
+	def.parsePipeline([]byte(`
+schedule:
+  cron: "0 6 * * mon"
+jobs:
+  daily:
+    schedule:
+      weekdays: [mon, tue, wed, thu, fri, sat, sun]
+    stages: {}
+  weekly:
+    stages: {}
+`), nil)
+
+	if a.True(def.IsValid(), "%v", def.Errors) {
+		a.Len(def.JobSchedule(def.Jobs["daily"]).Weekdays, 7)
+		a.Equal("0 6 * * mon", def.JobSchedule(def.Jobs["weekly"]).Cron)
+	}
+
+	def.parsePipeline([]byte(`
+schedule:
+  cron: "0 6 * *"
+jobs:
+  daily:
+    schedule:
+      weekdays: [lundi]
+    stages: {}
+`), nil)
+
+	var errors []string
+
+	for _, err := range def.Errors {
+		errors = append(errors, err.Error())
+	}
+
+	a.ElementsMatch([]string{
+		`pipeline.yaml: schedule.cron: invalid cron "0 6 * *", expected 5 fields`,
+		`pipeline.yaml: jobs.daily.schedule.weekdays: invalid week day "lundi"`,
+	}, errors)
+}
+
+func TestLoadCalendars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendars.yaml")
+
+	os.WriteFile(path, []byte("fr:\n  weekdays: [mon, tue, wed, thu, fri]\n  holidays: [\"2021-12-24\"]\n"), 0644)
+
+	calendars, err := LoadCalendars(path)
+
+	if assert.NoError(t, err) && assert.Contains(t, calendars, "fr") {
+		assert.False(t, calendars["fr"].IsBusinessDay(time.Date(2021, 12, 24, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, calendars["fr"].IsBusinessDay(time.Date(2021, 12, 23, 0, 0, 0, 0, time.UTC)))
+	}
+
+	os.WriteFile(path, []byte("fr:\n  holidays: [\"24/12/2021\"]\n"), 0644)
+
+	_, err = LoadCalendars(path)
+
+	assert.Error(t, err)
+}
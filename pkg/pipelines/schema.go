@@ -0,0 +1,466 @@
+package pipelines
+
+import (
+	_ "embed" // pipeline JSON schema
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed pipeline.schema.json
+var pipelineSchemaJSON []byte
+
+// PipelineSchema is the JSON schema of pipeline.yaml files.
+var PipelineSchema = mustLoadSchema(pipelineSchemaJSON)
+
+// ValidationError is a pipeline definition error, located in the YAML file.
+type ValidationError struct {
+	File         string
+	Line, Column int
+	Path         string
+	Message      string
+}
+
+func (e ValidationError) Error() string {
+	location := e.File
+
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+
+	if len(e.Path) > 0 {
+		return fmt.Sprintf("%s: %s: %s", location, e.Path, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+/**
+ * The subset of JSON schema used by pipeline.schema.json.
+ */
+type schema struct {
+	Ref                     string             `json:"$ref"`
+	RawType                 json.RawMessage    `json:"type"`
+	Properties              map[string]*schema `json:"properties"`
+	RawAdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Required                []string           `json:"required"`
+	Items                   *schema            `json:"items"`
+	Enum                    []string           `json:"enum"`
+	Definitions             map[string]*schema `json:"definitions"`
+
+	types                []string
+	additionalProperties *schema
+	noAdditional         bool
+}
+
+func mustLoadSchema(content []byte) *schema {
+	var root schema
+
+	if err := json.Unmarshal(content, &root); err != nil {
+		panic(fmt.Sprintf("invalid pipeline schema: %s", err))
+	}
+
+	if err := root.compile(&root); err != nil {
+		panic(fmt.Sprintf("invalid pipeline schema: %s", err))
+	}
+
+	return &root
+}
+
+/**
+ * Resolve "type" & "additionalProperties" polymorphic values, recursively.
+ */
+func (s *schema) compile(root *schema) error {
+	if len(s.RawType) > 0 {
+		if err := json.Unmarshal(s.RawType, &s.types); err != nil {
+			var t string
+
+			if err := json.Unmarshal(s.RawType, &t); err != nil {
+				return err
+			}
+
+			s.types = []string{t}
+		}
+	}
+
+	if len(s.RawAdditionalProperties) > 0 {
+		var allowed bool
+
+		if err := json.Unmarshal(s.RawAdditionalProperties, &allowed); err == nil {
+			s.noAdditional = !allowed
+		} else {
+			s.additionalProperties = &schema{}
+
+			if err := json.Unmarshal(s.RawAdditionalProperties, s.additionalProperties); err != nil {
+				return err
+			}
+		}
+	}
+
+	children := []*schema{s.Items, s.additionalProperties}
+
+	for _, child := range s.Properties {
+		children = append(children, child)
+	}
+
+	for _, child := range s.Definitions {
+		children = append(children, child)
+	}
+
+	for _, child := range children {
+		if child != nil {
+			if err := child.compile(root); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *schema) resolve(root *schema) *schema {
+	if len(s.Ref) == 0 {
+		return s
+	}
+
+	return root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")].resolve(root)
+}
+
+/**
+ * Validate pipeline YAML content against the schema, and report all errors & warnings.
+ */
+func (s *schema) Validate(file string, content []byte) ([]ValidationError, []ValidationError) {
+	node, errs := parseDocument(file, content)
+
+	if len(errs) > 0 {
+		return errs, nil
+	}
+
+	return s.ValidateNode(file, node, nil)
+}
+
+/**
+ * Validate a parsed document, origins locate nodes coming from other files (templates).
+ * Warnings are allowed unknown keys looking like a typo of a known one ("reportng").
+ */
+func (s *schema) ValidateNode(file string, node *yaml.Node, origins map[*yaml.Node]string) ([]ValidationError, []ValidationError) {
+	v := &validator{root: s, file: file, origins: origins}
+
+	v.validate(s, node, "")
+
+	return v.errors, v.warnings
+}
+
+/**
+ * Parse YAML content, return its root node.
+ */
+func parseDocument(file string, content []byte) (*yaml.Node, []ValidationError) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, []ValidationError{yamlSyntaxError(file, err)}
+	}
+
+	if len(document.Content) == 0 {
+		return nil, []ValidationError{{File: file, Message: "empty pipeline definition"}}
+	}
+
+	return resolveMerges(document.Content[0]), nil
+}
+
+/**
+ * Replace aliases by their anchored node, and "<<" merge keys by the merged pairs, like YAML decoding does:
+ * keys of the mapping win over merged ones, and the first merged mapping wins over the next ones.
+ */
+func resolveMerges(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		return resolveMerges(node.Alias)
+	}
+
+	for i, child := range node.Content {
+		node.Content[i] = resolveMerges(child)
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return node
+	}
+
+	var merged []*yaml.Node
+
+	content := make([]*yaml.Node, 0, len(node.Content))
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if sources, ok := mergeSources(key, value); ok {
+			for _, source := range sources {
+				merged = append(merged, source.Content...)
+			}
+		} else {
+			content = append(content, key, value)
+		}
+	}
+
+	for i := 0; i+1 < len(merged); i += 2 {
+		if !mappingHas(content, merged[i].Value) {
+			content = append(content, merged[i], merged[i+1])
+		}
+	}
+
+	node.Content = content
+
+	return node
+}
+
+/**
+ * Mappings of a "<<" key: a mapping or a list of mappings, else it is not a merge.
+ */
+func mergeSources(key, value *yaml.Node) ([]*yaml.Node, bool) {
+	if key.Kind != yaml.ScalarNode || key.ShortTag() != "!!merge" {
+		return nil, false
+	}
+
+	sources := []*yaml.Node{value}
+
+	if value.Kind == yaml.SequenceNode {
+		sources = value.Content
+	}
+
+	for _, source := range sources {
+		if source.Kind != yaml.MappingNode {
+			return nil, false
+		}
+	}
+
+	return sources, true
+}
+
+func mappingHas(content []*yaml.Node, key string) bool {
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i].Value == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+type validator struct {
+	root     *schema
+	file     string
+	origins  map[*yaml.Node]string
+	errors   []ValidationError
+	warnings []ValidationError
+}
+
+func (v *validator) fail(node *yaml.Node, path, format string, args ...interface{}) {
+	v.errors = append(v.errors, v.locate(node, path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) warn(node *yaml.Node, path, format string, args ...interface{}) {
+	v.warnings = append(v.warnings, v.locate(node, path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) locate(node *yaml.Node, path, message string) ValidationError {
+	file := v.file
+
+	if origin, ok := v.origins[node]; ok {
+		file = origin
+	}
+
+	return ValidationError{
+		File:    file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: message,
+	}
+}
+
+func (v *validator) validate(s *schema, node *yaml.Node, path string) {
+	s = s.resolve(v.root)
+
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	nodeType := yamlNodeType(node)
+
+	if len(s.types) > 0 && !typeAllowed(s.types, nodeType) {
+		v.fail(node, path, "must be %s, got %s", strings.Join(s.types, " or "), nodeType)
+		return
+	}
+
+	if len(s.Enum) > 0 && node.Kind == yaml.ScalarNode && !contains(s.Enum, node.Value) {
+		v.fail(node, path, "must be one of %s, got %q", strings.Join(s.Enum, ", "), node.Value)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		v.validateMapping(s, node, path)
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range node.Content {
+				v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *validator) validateMapping(s *schema, node *yaml.Node, path string) {
+	keys := make(map[string]bool)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := strings.TrimPrefix(path+"."+key.Value, ".")
+
+		keys[key.Value] = true
+
+		if property, ok := s.Properties[key.Value]; ok {
+			v.validate(property, value, keyPath)
+		} else if s.additionalProperties != nil {
+			v.validate(s.additionalProperties, value, keyPath)
+		} else if s.noAdditional {
+			v.fail(key, path, "unknown key %q (expected one of %s)", key.Value, strings.Join(s.propertyNames(), ", "))
+		} else if suggestion := s.closestProperty(key.Value); len(suggestion) > 0 {
+			v.warn(key, path, "unknown key %q is ignored, did you mean %q?", key.Value, suggestion)
+		}
+	}
+
+	for _, required := range s.Required {
+		if !keys[required] {
+			v.fail(node, path, "missing required key %q", required)
+		}
+	}
+}
+
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+/**
+ * Locate "yaml: line 2: did not find expected node content" errors.
+ */
+func yamlSyntaxError(file string, err error) ValidationError {
+	if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+
+		return ValidationError{File: file, Line: line, Column: 1, Message: match[2]}
+	}
+
+	return ValidationError{File: file, Message: err.Error()}
+}
+
+func (s *schema) propertyNames() []string {
+	var names []string
+
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+/**
+ * Known property close to an unknown key (1 edit for short names, else 2), empty if none.
+ */
+func (s *schema) closestProperty(key string) string {
+	closest, closestDistance := "", 0
+
+	for _, name := range s.propertyNames() {
+		maxDistance := 2
+
+		if len(name) <= 4 {
+			maxDistance = 1
+		}
+
+		if distance := editDistance(key, name); distance <= maxDistance && (len(closest) == 0 || distance < closestDistance) {
+			closest, closestDistance = name, distance
+		}
+	}
+
+	return closest
+}
+
+/**
+ * Levenshtein distance of strings.
+ */
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	ret := values[0]
+
+	for _, v := range values[1:] {
+		if v < ret {
+			ret = v
+		}
+	}
+
+	return ret
+}
+
+func yamlNodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+
+	return "string"
+}
+
+func typeAllowed(types []string, nodeType string) bool {
+	return contains(types, nodeType) || (nodeType == "integer" && contains(types, "number"))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
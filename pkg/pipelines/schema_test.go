@@ -0,0 +1,153 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name, content string
+		errors        []string
+		warnings      []string
+	}{
+		{
+			name: "valid",
+			content: `
+jobs:
+  conso:
+    contexts:
+      a:
+        label: a
+      b:
+    stages:
+      output:
+        kind: org.elasticsearch.output
+        enabled: false
+meta:
+  team: team_a
+  owners:
+    - name: bob
+      email: bob@localhost
+reporting:
+  enabled: true
+djobi_version: 4
+`,
+		},
+		{
+			name: "anchors and merge keys",
+			content: `
+x-output: &output
+  kind: org.elasticsearch.output
+  unit: documents
+jobs:
+  conso:
+    stages:
+      output:
+        <<: *output
+        enabled: false
+      archive:
+        <<: [*output]
+`,
+		},
+		{
+			name: "all errors, with lines",
+			content: `
+jobs:
+  conso:
+    stages:
+      output:
+        kindd: output
+meta:
+  owners:
+    - name: bob
+reporting:
+  enabled: nop
+`,
+			errors: []string{
+				`pipeline.yaml:6:9: jobs.conso.stages.output: unknown key "kindd" (expected one of allow_empty, condition, config, enabled, extends, kind, labels, max_value, min_value, name, parameters, spec, stage, type, unit)`,
+				`pipeline.yaml:9:7: meta.owners[0]: missing required key "email"`,
+				`pipeline.yaml:11:12: reporting.enabled: must be boolean, got string`,
+			},
+		},
+		{
+			name:    "typos of tintin keys",
+			content: "jobs: {}\nreportng:\n  enabled: false\nschedul: {}\nmetas: {}\nsteps: []\n",
+			warnings: []string{
+				`pipeline.yaml:2:1: unknown key "reportng" is ignored, did you mean "reporting"?`,
+				`pipeline.yaml:4:1: unknown key "schedul" is ignored, did you mean "schedule"?`,
+				`pipeline.yaml:5:1: unknown key "metas" is ignored, did you mean "meta"?`,
+			},
+		},
+		{
+			name:    "missing jobs",
+			content: "meta: {}\n",
+			errors:  []string{`pipeline.yaml:1:1: missing required key "jobs"`},
+		},
+		{
+			name:    "bad YAML",
+			content: "jobs:\n  a: [\n",
+			errors:  []string{`pipeline.yaml:2:1: did not find expected node content`},
+		},
+		{
+			name:    "empty",
+			content: "",
+			errors:  []string{`pipeline.yaml: empty pipeline definition`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errors, warnings []string
+
+			errs, warns := PipelineSchema.Validate("pipeline.yaml", []byte(tt.content))
+
+			for _, err := range errs {
+				errors = append(errors, err.Error())
+			}
+
+			for _, warning := range warns {
+				warnings = append(warnings, warning.Error())
+			}
+
+			assert.Equal(t, tt.errors, errors)
+			assert.Equal(t, tt.warnings, warnings)
+		})
+	}
+}
+
+func TestDefinition_parsePipelineInvalid(t *testing.T) {
+	def := defaultPipelineDefinition("pipeline.yaml", "team_a/a", "a", "team_a", "")
+
+	def.parsePipeline([]byte("jobs:\n  a:\n    stages: []\n"), nil)
+
+	if assert.False(t, def.IsValid()) {
+		assert.Equal(t, "pipeline.yaml:3:13: jobs.a.stages: must be object, got array", def.Errors[0].Error())
+	}
+}
+
+func TestDefinition_parsePipelineMergeKeys(t *testing.T) {
+	def := defaultPipelineDefinition("pipeline.yaml", "team_a/a", "a", "team_a", "")
+
+	def.parsePipeline([]byte(`
+x-stage: &stage
+  kind: org.elasticsearch.output
+  unit: documents
+  enabled: false
+jobs:
+  a:
+    stages:
+      output:
+        <<: *stage
+        enabled: true
+`), nil)
+
+	if assert.True(t, def.IsValid(), "%v", def.Errors) {
+		output := def.Jobs["a"].Stages["output"]
+
+		assert.Equal(t, "org.elasticsearch.output", output.Kind)
+		assert.Equal(t, "documents", output.Unit)
+		assert.True(t, output.IsEnabled(), "keys of the mapping must win over merged ones")
+	}
+}
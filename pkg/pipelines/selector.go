@@ -0,0 +1,87 @@
+package pipelines
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/datatok/tintin/pkg/utils/myglob"
+)
+
+const (
+	SelectorExcludePrefix = "!"
+	SelectorRegexPrefix   = "re:"
+)
+
+var ErrInvalidSelector = errors.New("invalid pipeline selector")
+
+/**
+ * Select pipelines by full name, from include & exclude patterns:
+ * "team_a/**" glob, "!**\/sandbox/*" exclude glob, "re:^team_(a|b)/" regex.
+ */
+type Selector struct {
+	includes, excludes []*regexp.Regexp
+}
+
+func NewSelector(patterns []string) (*Selector, error) {
+	selector := &Selector{}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+
+		// "*" was the legacy "all pipelines" value
+		if len(pattern) == 0 || pattern == "*" {
+			continue
+		}
+
+		exclude := strings.HasPrefix(pattern, SelectorExcludePrefix)
+		expression := strings.TrimPrefix(pattern, SelectorExcludePrefix)
+
+		var (
+			reg *regexp.Regexp
+			err error
+		)
+
+		if strings.HasPrefix(expression, SelectorRegexPrefix) {
+			reg, err = regexp.Compile(strings.TrimPrefix(expression, SelectorRegexPrefix))
+		} else {
+			reg, err = myglob.Compile(expression)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %s", ErrInvalidSelector, pattern, err)
+		}
+
+		if exclude {
+			selector.excludes = append(selector.excludes, reg)
+		} else {
+			selector.includes = append(selector.includes, reg)
+		}
+	}
+
+	return selector, nil
+}
+
+/**
+ * Is full name included (or no include pattern), and not excluded.
+ */
+func (s *Selector) Match(fullName string) bool {
+	for _, reg := range s.excludes {
+		if reg.MatchString(fullName) {
+			return false
+		}
+	}
+
+	if len(s.includes) == 0 {
+		return true
+	}
+
+	for _, reg := range s.includes {
+		if reg.MatchString(fullName) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package pipelines
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelector_Match(t *testing.T) {
+	names := []string{"team_a/archivr", "team_a/sandbox/test", "team_b/archivr", "team_b/conso/daily"}
+
+	tests := []struct {
+		patterns []string
+		expected []string
+	}{
+		{nil, names},
+		{[]string{"*"}, names},
+		{[]string{"team_a/**"}, []string{"team_a/archivr", "team_a/sandbox/test"}},
+		{[]string{"!**/sandbox/*"}, []string{"team_a/archivr", "team_b/archivr", "team_b/conso/daily"}},
+		{[]string{"team_a/**", "!**/sandbox/*"}, []string{"team_a/archivr"}},
+		{[]string{"*/archivr", "team_b/*"}, []string{"team_a/archivr", "team_b/archivr"}},
+		{[]string{"re:daily$", "team_a/archivr"}, []string{"team_a/archivr", "team_b/conso/daily"}},
+		{[]string{"!re:^team_a"}, []string{"team_b/archivr", "team_b/conso/daily"}},
+	}
+
+	for _, tt := range tests {
+		selector, err := NewSelector(tt.patterns)
+
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var matches []string
+
+		for _, name := range names {
+			if selector.Match(name) {
+				matches = append(matches, name)
+			}
+		}
+
+		assert.Equal(t, tt.expected, matches, "%v", tt.patterns)
+	}
+
+	for _, pattern := range []string{"re:team_(a", "team_[a"} {
+		_, err := NewSelector([]string{pattern})
+
+		assert.True(t, errors.Is(err, ErrInvalidSelector), pattern)
+	}
+}
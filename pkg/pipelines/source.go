@@ -0,0 +1,118 @@
+package pipelines
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/links"
+)
+
+const (
+	ConflictFirst = "first"
+	ConflictLast  = "last"
+	ConflictError = "error"
+)
+
+// Kinds of sources, for pipelines excludes
+const (
+	SourceKindS3   = "s3"
+	SourceKindFile = "file"
+	SourceKindGit  = "git"
+)
+
+// Source provides pipeline definitions, from a git repository, a local directory, a S3 bucket...
+type Source interface {
+	// URL of the source, without credentials
+	URL() string
+
+	// Definitions finds and parses all pipeline definitions. Sources may cache them (git, watched files):
+	// the slice is the caller's, but maps of definitions (jobs, stages, contexts, labels) are shared and read-only
+	Definitions() ([]Definition, error)
+
+	// Status is a human readable health status
+	Status() string
+}
+
+// SourceFactory builds a source from its URL.
+type SourceFactory func(u *url.URL, settings *cli.EnvSettings, linksRepository links.Repository) (Source, error)
+
+var sourceFactories = map[string]SourceFactory{}
+
+// RegisterSource registers a source factory, for an URL scheme (such as "s3").
+func RegisterSource(scheme string, factory SourceFactory) {
+	sourceFactories[scheme] = factory
+}
+
+/**
+ * Build the source from its URL.
+ * An empty URL is the local PipelinesPath directory, an URL without registered scheme is a git remote.
+ */
+func NewSource(rawURL string, settings *cli.EnvSettings, linksRepository links.Repository) (Source, error) {
+	if len(rawURL) == 0 {
+		rawURL = "file://" + settings.PipelinesPath
+	}
+
+	u, err := url.Parse(rawURL)
+
+	if err != nil || len(u.Scheme) == 0 || sourceFactories[u.Scheme] == nil {
+		// Legacy: git remote URL (https://, ssh scp-like syntax, local path)
+		u = &url.URL{Scheme: "git+legacy", Opaque: rawURL}
+	}
+
+	return sourceFactories[u.Scheme](u, settings, linksRepository)
+}
+
+/**
+ * Merge definitions of all sources, by pipeline full name.
+ */
+func mergeDefinitions(bySource [][]Definition, conflict string) ([]Definition, error) {
+	var (
+		ret   []Definition
+		index = make(map[string]int)
+	)
+
+	for _, definitions := range bySource {
+		for _, definition := range definitions {
+			i, exists := index[definition.FullName]
+
+			if !exists {
+				index[definition.FullName] = len(ret)
+				ret = append(ret, definition)
+
+				continue
+			}
+
+			existing := ret[i]
+
+			switch conflict {
+			case ConflictError:
+				return nil, fmt.Errorf("pipeline %s is defined by both %s and %s", definition.FullName, existing.Source, definition.Source)
+			case ConflictLast:
+				logrus.Warnf("pipeline %s from %s overrides %s", definition.FullName, definition.Source, existing.Source)
+				ret[i] = definition
+			default:
+				logrus.Warnf("pipeline %s from %s is ignored, already defined by %s", definition.FullName, definition.Source, existing.Source)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+/**
+ * Remove password (or token) from URL, to not leak it into reports.
+ */
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+
+	u.User = url.User(u.User.Username())
+
+	return u.String()
+}
@@ -0,0 +1,47 @@
+package pipelines
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+/**
+ * Read a file of pipelines definitions source (templates, contexts files...),
+ * path is relative to the definitions root. Return its display path & content.
+ */
+type SourceReader func(path string) (string, []byte, error)
+
+/**
+ * Clean relative path, reject paths out of definitions root.
+ */
+func cleanSourcePath(p string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(p))
+
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || path.IsAbs(cleaned) {
+		return "", fmt.Errorf("invalid path %q, out of pipelines directory", p)
+	}
+
+	return cleaned, nil
+}
+
+/**
+ * Read files of a local pipelines directory.
+ */
+func dirSourceReader(root string) SourceReader {
+	return func(p string) (string, []byte, error) {
+		cleaned, err := cleanSourcePath(p)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		filePath := filepath.Join(root, filepath.FromSlash(cleaned))
+
+		dat, err := os.ReadFile(filePath)
+
+		return filePath, dat, err
+	}
+}
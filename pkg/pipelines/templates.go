@@ -0,0 +1,296 @@
+package pipelines
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Directory of shared templates, at the root of pipelines definitions
+	TemplatesDir = "_templates"
+
+	extendsKey = "extends"
+)
+
+/**
+ * Resolve "extends" of document, jobs and stages, from templates: templates are deep-merged
+ * in order, then overridden by the extending block.
+ */
+type templateExpander struct {
+	file string
+	read SourceReader
+
+	// File of nodes copied from templates, for errors location
+	origins map[*yaml.Node]string
+
+	errors []ValidationError
+}
+
+/**
+ * Parse pipeline content, expand its templates and validate the result,
+ * return the expanded YAML content, and validation warnings.
+ */
+func expandDocument(file string, content []byte, read SourceReader) ([]byte, []ValidationError, []ValidationError) {
+	document, errs := parseDocument(file, content)
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	expanded, origins, errs := expandTemplates(file, document, read)
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	errs, warnings := PipelineSchema.ValidateNode(file, expanded, origins)
+
+	if len(errs) > 0 {
+		return nil, warnings, errs
+	}
+
+	ret, err := yaml.Marshal(expanded)
+
+	if err != nil {
+		return nil, warnings, []ValidationError{{File: file, Message: err.Error()}}
+	}
+
+	return ret, warnings, nil
+}
+
+func expandTemplates(file string, document *yaml.Node, read SourceReader) (*yaml.Node, map[*yaml.Node]string, []ValidationError) {
+	e := &templateExpander{
+		file:    file,
+		read:    read,
+		origins: make(map[*yaml.Node]string),
+	}
+
+	document = e.expand(document, "", nil)
+
+	for _, job := range mappingValues(mappingGet(document, "jobs")) {
+		expanded := e.expand(job.value, "jobs."+job.key, nil)
+
+		stages := mappingGet(expanded, "stages")
+
+		for _, stage := range mappingValues(stages) {
+			mappingSet(stages, stage.key, e.expand(stage.value, "jobs."+job.key+".stages."+stage.key, nil))
+		}
+
+		mappingSet(mappingGet(document, "jobs"), job.key, expanded)
+	}
+
+	return document, e.origins, e.errors
+}
+
+func (e *templateExpander) fail(node *yaml.Node, path, format string, args ...interface{}) {
+	file := e.file
+
+	if origin, ok := e.origins[node]; ok {
+		file = origin
+	}
+
+	e.errors = append(e.errors, ValidationError{
+		File:    file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+/**
+ * Expand "extends" of a mapping, recursively (templates can extend templates).
+ */
+func (e *templateExpander) expand(node *yaml.Node, path string, stack []string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return node
+	}
+
+	extends := mappingGet(node, extendsKey)
+
+	if extends == nil {
+		return node
+	}
+
+	var names []*yaml.Node
+
+	switch extends.Kind {
+	case yaml.ScalarNode:
+		names = []*yaml.Node{extends}
+	case yaml.SequenceNode:
+		names = extends.Content
+	default:
+		e.fail(extends, path, "extends must be a template name, or a list of names")
+		return node
+	}
+
+	base := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for _, nameNode := range names {
+		name := nameNode.Value
+
+		if contains(stack, name) {
+			e.fail(nameNode, path, "template cycle %s -> %s", strings.Join(stack, " -> "), name)
+			continue
+		}
+
+		template := e.load(nameNode, path)
+
+		if template != nil {
+			base = mergeNodes(base, e.expand(template, path, append(stack, name)))
+		}
+	}
+
+	return mergeNodes(base, mappingWithout(node, extendsKey))
+}
+
+/**
+ * Read "_templates/name.yaml" (or .yml).
+ */
+func readTemplate(read SourceReader, name string) (string, []byte, error) {
+	path, content, err := read(TemplatesDir + "/" + name + ".yaml")
+
+	if err != nil {
+		return read(TemplatesDir + "/" + name + ".yml")
+	}
+
+	return path, content, err
+}
+
+/**
+ * Load template as a fresh copy, to merge it safely.
+ */
+func (e *templateExpander) load(nameNode *yaml.Node, path string) *yaml.Node {
+	name := nameNode.Value
+
+	if e.read == nil {
+		e.fail(nameNode, path, "templates are not supported by this source")
+		return nil
+	}
+
+	if len(name) == 0 || strings.Contains(name, "..") {
+		e.fail(nameNode, path, "invalid template name %q", name)
+		return nil
+	}
+
+	templatePath, content, err := readTemplate(e.read, name)
+
+	if err != nil {
+		e.fail(nameNode, path, "unknown template %q", name)
+		return nil
+	}
+
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		e.errors = append(e.errors, yamlSyntaxError(templatePath, err))
+		return nil
+	}
+
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		e.errors = append(e.errors, ValidationError{File: templatePath, Message: "template must be a mapping"})
+		return nil
+	}
+
+	return e.copy(resolveMerges(document.Content[0]), templatePath)
+}
+
+func (e *templateExpander) copy(node *yaml.Node, file string) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	ret := *node
+	ret.Content = nil
+
+	for _, child := range node.Content {
+		ret.Content = append(ret.Content, e.copy(child, file))
+	}
+
+	e.origins[&ret] = file
+
+	return &ret
+}
+
+/**
+ * Deep-merge mappings, override wins. Other nodes (scalars, lists) are replaced.
+ */
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	ret := *override
+	ret.Content = nil
+
+	for _, pair := range mappingValues(base) {
+		ret.Content = append(ret.Content, pair.keyNode, pair.value)
+	}
+
+	for _, pair := range mappingValues(override) {
+		if existing := mappingGet(&ret, pair.key); existing != nil {
+			mappingSet(&ret, pair.key, mergeNodes(existing, pair.value))
+		} else {
+			ret.Content = append(ret.Content, pair.keyNode, pair.value)
+		}
+	}
+
+	return &ret
+}
+
+type mappingPair struct {
+	key            string
+	keyNode, value *yaml.Node
+}
+
+func mappingValues(node *yaml.Node) []mappingPair {
+	var ret []mappingPair
+
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		ret = append(ret, mappingPair{node.Content[i].Value, node.Content[i], node.Content[i+1]})
+	}
+
+	return ret
+}
+
+func mappingGet(node *yaml.Node, key string) *yaml.Node {
+	for _, pair := range mappingValues(node) {
+		if pair.key == key {
+			return pair.value
+		}
+	}
+
+	return nil
+}
+
+func mappingSet(node *yaml.Node, key string, value *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+}
+
+func mappingWithout(node *yaml.Node, key string) *yaml.Node {
+	ret := *node
+	ret.Content = nil
+
+	for _, pair := range mappingValues(node) {
+		if pair.key != key {
+			ret.Content = append(ret.Content, pair.keyNode, pair.value)
+		}
+	}
+
+	return &ret
+}
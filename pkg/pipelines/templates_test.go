@@ -0,0 +1,113 @@
+package pipelines
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mapSourceReader(files map[string]string) SourceReader {
+	return func(path string) (string, []byte, error) {
+		content, ok := files[path]
+
+		if !ok {
+			return "", nil, fmt.Errorf("file %s not found", path)
+		}
+
+		return path, []byte(content), nil
+	}
+}
+
+func TestDefinition_parsePipelineTemplates(t *testing.T) {
+	a := assert.New(t)
+
+	templates := mapSourceReader(map[string]string{
+		"_templates/base.yaml":   "meta:\n  team: team_x\njobs:\n  daily:\n    extends: es_job\n",
+		"_templates/es_job.yaml": "schedule:\n  weekdays: [mon]\nstages:\n  input:\n    extends: input\n  output:\n    type: output\n    unit: documents\n",
+		"_templates/input.yaml":  "kind: input\nmin_value: 10\nunit: lines\n",
+	})
+
+	def := defaultPipelineDefinition("pipeline.yaml", "team_a/a", "a", "team_a", "")
+
+	def.parsePipeline([]byte(`
+extends: base
+jobs:
+  daily:
+    schedule:
+      weekdays: [tue]
+    stages:
+      input:
+        min_value: 100
+  weekly:
+    extends: es_job
+    stages:
+      output:
+        enabled: false
+`), templates)
+
+	if a.True(def.IsValid(), "%v", def.Errors) {
+		a.Equal("team_x", def.Team)
+		a.Len(def.Jobs, 2)
+
+		daily := def.Jobs["daily"]
+
+		a.Equal([]string{"tue"}, daily.Schedule.Weekdays)
+		a.Len(daily.Stages, 2)
+		a.Equal("input", daily.Stages["input"].Kind)
+		a.Equal(int64(100), *daily.Stages["input"].MinValue)
+		a.Equal("lines", daily.Stages["input"].Unit)
+
+		weekly := def.Jobs["weekly"]
+
+		a.Equal([]string{"mon"}, weekly.Schedule.Weekdays)
+		a.Equal("documents", weekly.Stages["output"].Unit)
+		a.False(weekly.Stages["output"].IsEnabled())
+		a.True(daily.Stages["output"].IsEnabled())
+	}
+}
+
+func TestDefinition_parsePipelineTemplatesErrors(t *testing.T) {
+	templates := mapSourceReader(map[string]string{
+		"_templates/a.yaml":   "extends: b\n",
+		"_templates/b.yaml":   "extends: a\n",
+		"_templates/bad.yaml": "stages:\n  input:\n    kindd: es\n",
+	})
+
+	tests := []struct {
+		name, content string
+		errors        []string
+	}{
+		{
+			"unknown template",
+			"jobs:\n  daily:\n    extends: nope\n",
+			[]string{`pipeline.yaml:3:14: jobs.daily: unknown template "nope"`},
+		},
+		{
+			"cycle",
+			"jobs:\n  daily:\n    extends: a\n",
+			[]string{"_templates/b.yaml:1:10: jobs.daily: template cycle a -> b -> a"},
+		},
+		{
+			"invalid template, located in template file",
+			"jobs:\n  daily:\n    extends: bad\n",
+			[]string{`_templates/bad.yaml:3:5: jobs.daily.stages.input: unknown key "kindd" (expected one of allow_empty, condition, config, enabled, extends, kind, labels, max_value, min_value, name, parameters, spec, stage, type, unit)`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := defaultPipelineDefinition("pipeline.yaml", "team_a/a", "a", "team_a", "")
+
+			def.parsePipeline([]byte(tt.content), templates)
+
+			var errors []string
+
+			for _, err := range def.Errors {
+				errors = append(errors, err.Error())
+			}
+
+			assert.Equal(t, tt.errors, errors)
+		})
+	}
+}
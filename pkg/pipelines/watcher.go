@@ -0,0 +1,88 @@
+package pipelines
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+/**
+ * Recursive directory watcher, with fsnotify: changed paths are sent as they come.
+ */
+type fileWatcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	Events  chan []string
+}
+
+func newFileWatcher() (*fileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, err
+	}
+
+	w := &fileWatcher{
+		watcher: watcher,
+		done:    make(chan struct{}),
+		Events:  make(chan []string),
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+/**
+ * Watch directory and its sub-directories.
+ */
+func (w *fileWatcher) AddRecursive(root string) error {
+	return filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+
+		return w.watcher.Add(path)
+	})
+}
+
+func (w *fileWatcher) Close() {
+	close(w.done)
+	w.watcher.Close()
+}
+
+func (w *fileWatcher) run() {
+	defer close(w.Events)
+
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			logrus.Warnf("pipelines watcher error: %s", err)
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+
+			// Only permissions changed
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			select {
+			case w.Events <- []string{filepath.Clean(event.Name)}:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
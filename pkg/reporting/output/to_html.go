@@ -3,6 +3,7 @@ package output
 import (
 	"bytes"
 	"fmt"
+	"html"
 
 	"html/template"
 	"io"
@@ -61,6 +62,7 @@ func (rHTML *ReportHTML) ToHTML(out io.Writer) {
 			"pipeline_color": pipelineColor,
 			"nl2br":          Nl2Br,
 			"percentage":     Percentage,
+			"phases":         PhasesIndicator,
 		}).Parse(htmlAsStr)
 
 		if errT != nil {
@@ -119,6 +121,8 @@ func (rHTML *ReportHTML) LinkTo(k string, v string) string {
 		cloneLink.Arguments.Team = v
 	case "pipeline":
 		cloneLink.Arguments.Pipeline = v
+	case "owner":
+		cloneLink.Arguments.Owner = v
 	case "status":
 		cloneLink.Arguments.Status = []string{v}
 	}
@@ -155,42 +159,100 @@ func ParseTime(d string) string {
 }
 
 func pipelineColor(pipeline reporting.Pipeline) string {
+	var works []reporting.Work
 
 	for _, job := range pipeline.Jobs {
-		for _, work := range job.Works {
-			if work.Status == constant.DoneError {
-				return "danger"
-			}
-		}
+		works = append(works, job.Works...)
 	}
 
-	for _, job := range pipeline.Jobs {
-		for _, work := range job.Works {
-			if !work.Success {
-				return "warning"
-			}
-		}
-	}
-
-	return "success"
+	return worksColor(works)
 }
 
 func jobColor(job reporting.Job) string {
-	for _, work := range job.Works {
+	return worksColor(job.Works)
+}
+
+/**
+ * Worst color of works: errors, then warnings, then unavailable data.
+ */
+func worksColor(works []reporting.Work) string {
+	for _, work := range works {
 		if work.Status == constant.DoneError {
 			return "danger"
 		}
 	}
 
-	for _, work := range job.Works {
-		if !work.Success {
+	for _, work := range works {
+		if !work.Success && work.Status != constant.Skipped && work.Status != constant.DataUnavailable {
 			return "warning"
 		}
 	}
 
+	for _, work := range works {
+		if work.Status == constant.DataUnavailable {
+			return "dark"
+		}
+	}
+
 	return "success"
 }
 
+/**
+ * Three-segment indicator of stage phases: pre-check, run & post-check.
+ */
+func PhasesIndicator(stage reporting.WorkStageDetails) template.HTML {
+	phases := []struct {
+		name   string
+		status reporting.Status
+	}{
+		{"pre-check", stage.PreCheck},
+		{"run", stage.Run},
+		{"post-check", stage.PostCheck},
+	}
+
+	var buffer strings.Builder
+
+	buffer.WriteString(`<span class="phases">`)
+
+	for _, phase := range phases {
+		title := phase.name + ": " + phase.status.Status
+
+		if len(phase.status.Status) == 0 {
+			title = phase.name + ": not logged"
+		}
+
+		if len(phase.status.Details) > 0 {
+			title += " - " + phase.status.Details
+		}
+
+		segment := fmt.Sprintf(`<span class="phase bdg_%s" title="%s"></span>`, phaseColor(phase.status.Status), html.EscapeString(title))
+
+		// Links come from djobi logs, only web ones
+		if strings.HasPrefix(phase.status.Link, "http://") || strings.HasPrefix(phase.status.Link, "https://") {
+			segment = fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, html.EscapeString(phase.status.Link), segment)
+		}
+
+		buffer.WriteString(segment)
+	}
+
+	buffer.WriteString(`</span>`)
+
+	return template.HTML(buffer.String())
+}
+
+func phaseColor(status string) string {
+	switch status {
+	case constant.DoneOk:
+		return "success"
+	case constant.DoneError:
+		return "danger"
+	case "", constant.No:
+		return "secondary"
+	}
+
+	return "warning"
+}
+
 func Percentage(a int, b int) string {
 	if b == 0 {
 		return "0%"
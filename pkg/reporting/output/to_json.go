@@ -0,0 +1,18 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/datatok/tintin/pkg/reporting"
+)
+
+/**
+ * Write the report as indented JSON, with data source errors.
+ */
+func ToJSON(out io.Writer, report *reporting.Report) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/datatok/tintin/pkg/utils"
+	"github.com/datatok/tintin/pkg/utils/cli"
+	"github.com/datatok/tintin/pkg/utils/esclient"
 
 	"github.com/Rican7/conjson"
 	"github.com/Rican7/conjson/transform"
@@ -33,7 +35,7 @@ type DocumentAggregated struct {
 */
 
 type DocumentStorePipeline struct {
-	Name, Team, File string
+	Name, Team, File, Revision string
 }
 
 type DocumentStoreJob struct {
@@ -64,17 +66,26 @@ type DocumentStore struct {
 	Date     string `json:"timestamp"`
 }
 
-func NewStore() *SaveStore {
-	es, err := elasticsearch.NewDefaultClient()
+/**
+ * Report store, on ReportSaveURL, or djobi logs elasticsearch.
+ */
+func NewStore(settings *cli.EnvSettings) (*SaveStore, error) {
+	url := settings.ReportSaveURL
+
+	if len(url) == 0 {
+		url = settings.MetricsLogAPIURL
+	}
+
+	es, err := esclient.New(settings, url)
 
 	if err != nil {
-		log.Fatalf("Error creating the client: %s", err)
+		return nil, fmt.Errorf("unable to create report store client: %w", err)
 	}
 
 	return &SaveStore{
 		es,
 		"djobi-tintin",
-	}
+	}, nil
 }
 
 func (thisRepo *SaveStore) SaveReport(report *reporting.Report) {
@@ -136,9 +147,10 @@ func (thisRepo *SaveStore) saveReportRaw(report *reporting.Report) {
 					doc := DocumentStore{
 						Date: time.Now().Format(time.RFC3339),
 						Pipeline: DocumentStorePipeline{
-							Name: pipeline.Definition.Name,
-							Team: pipeline.Definition.Team,
-							File: pipeline.Definition.Path,
+							Name:     pipeline.Definition.Name,
+							Team:     pipeline.Definition.Team,
+							File:     pipeline.Definition.Path,
+							Revision: pipeline.Definition.Revision,
 						},
 						Job: DocumentStoreJob{
 							ID:      work.Name,
@@ -1,19 +1,88 @@
 package output
 
 import (
+	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/olekukonko/tablewriter"
 
+	"github.com/datatok/tintin/pkg/pipelines"
 	"github.com/datatok/tintin/pkg/reporting"
+	"github.com/datatok/tintin/pkg/utils/constant"
 )
 
+func ownersNames(owners []pipelines.MetaOwnerDefinition) string {
+	var names []string
+
+	for _, owner := range owners {
+		if len(owner.Name) > 0 {
+			names = append(names, owner.Name)
+		} else {
+			names = append(names, owner.Email)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+/**
+ * Phase of works stages: not ok ones as "context stage: status", else DONE_OK, or "not logged" (neutral) if no stage logged it.
+ */
+func phaseCell(works []reporting.Work, phase func(stage reporting.WorkStageDetails) reporting.Status) (string, int) {
+	var (
+		failing []string
+		ok      bool
+		color   = tablewriter.FgGreenColor
+	)
+
+	for _, work := range works {
+		var stageNames []string
+
+		for stageName := range work.Stages {
+			stageNames = append(stageNames, stageName)
+		}
+
+		sort.Strings(stageNames)
+
+		for _, stageName := range stageNames {
+			status := phase(work.Stages[stageName]).Status
+
+			switch status {
+			case "":
+				continue
+			case constant.DoneOk:
+				ok = true
+				continue
+			case constant.DoneError:
+				color = tablewriter.FgRedColor
+			default:
+				if color != tablewriter.FgRedColor {
+					color = tablewriter.FgYellowColor
+				}
+			}
+
+			failing = append(failing, work.Context.Name+" "+stageName+": "+status)
+		}
+	}
+
+	if len(failing) == 0 {
+		if ok {
+			return constant.DoneOk, color
+		}
+
+		return "not logged", tablewriter.Normal
+	}
+
+	return strings.Join(failing, ", "), color
+}
+
 func ToTable(out io.Writer, report *reporting.Report) {
 	data := [][]string{}
 
 	table := tablewriter.NewWriter(out)
-	table.SetHeader([]string{"Pipeline", "Job", "Contexts"})
+	table.SetHeader([]string{"Pipeline", "Owners", "Job", "Contexts", "Pre-check", "Run", "Post-check"})
 
 	for _, pipeline := range report.Pipelines {
 
@@ -25,20 +94,46 @@ func ToTable(out io.Writer, report *reporting.Report) {
 			)
 
 			for _, c := range job.Works {
+				if c.Status == constant.DataUnavailable {
+					contexts = contexts + ", " + c.Context.Name + " (" + c.Status + ": " + c.Details + ")"
+
+					if color == tablewriter.FgGreenColor {
+						color = tablewriter.FgYellowColor
+					}
+
+					continue
+				}
+
 				contexts = contexts + ", " + c.Context.Name + " (" + c.Details + ")"
 
-				if !c.Success {
+				if summary := c.RetrySummary(); len(summary) > 0 {
+					contexts = contexts + " [" + summary + "]"
+				}
+
+				for _, warning := range c.Warnings {
+					contexts = contexts + " [warning: " + warning + "]"
+				}
+
+				if !c.Success && c.Status != constant.Skipped {
 					color = tablewriter.FgRedColor
 				}
 			}
 
 			contexts = strings.Trim(strings.Trim(contexts, ", "), " ")
 
+			preCheck, preCheckColor := phaseCell(job.Works, func(stage reporting.WorkStageDetails) reporting.Status { return stage.PreCheck })
+			run, runColor := phaseCell(job.Works, func(stage reporting.WorkStageDetails) reporting.Status { return stage.Run })
+			postCheck, postCheckColor := phaseCell(job.Works, func(stage reporting.WorkStageDetails) reporting.Status { return stage.PostCheck })
+
 			table.Rich([]string{
 				pipeline.Definition.Team + " > " + pipeline.Definition.Name,
+				ownersNames(pipeline.Definition.Meta.Owners),
 				job.Name,
 				contexts,
-			}, []tablewriter.Colors{{}, {}, {color}})
+				preCheck,
+				run,
+				postCheck,
+			}, []tablewriter.Colors{{}, {}, {}, {color}, {preCheckColor}, {runColor}, {postCheckColor}})
 
 		}
 	}
@@ -47,4 +142,16 @@ func ToTable(out io.Writer, report *reporting.Report) {
 		table.Append(v)
 	}
 	table.Render() // Send output
+
+	for _, e := range report.Errors {
+		fmt.Fprintf(out, "Data source error %s: %s\n", e.Source, e.Message)
+	}
+
+	for _, pipeline := range report.InvalidPipelines {
+		fmt.Fprintf(out, "Invalid pipeline %s, not checked:\n", pipeline.FullName)
+
+		for _, err := range pipeline.Errors {
+			fmt.Fprintf(out, "  %s\n", err)
+		}
+	}
 }
@@ -1,6 +1,7 @@
 package reporting
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
@@ -45,9 +46,97 @@ type Work struct {
 
 	Success bool
 
+	// Doubts on the checked execution, like executions of several pipelines matching the work
+	Warnings []string
+
+	// Executions of the work (djobi retries), oldest first: the latest decides the status
+	Attempts []Attempt
+
 	Name, Status, Details, Link, LinkToJobLogs, LinkToJobStagesLogs, LinkToSparkHistory, LinkToYARNHistory string
 }
 
+/**
+ * Execution of a work, and its checked status.
+ */
+type Attempt struct {
+	Number   int
+	UID      string
+	Status   string
+	Success  bool
+	Details  string
+	Timeline utils.ExecutionTimeline
+
+	LinkToJobLogs, LinkToJobStagesLogs string
+}
+
+/**
+ * Retries summary, like "failed twice, succeeded on 3rd attempt", empty without retry.
+ */
+func (w Work) RetrySummary() string {
+	if len(w.Attempts) < 2 {
+		return ""
+	}
+
+	var (
+		parts           []string
+		failed, success int
+	)
+
+	for _, attempt := range w.Attempts[:len(w.Attempts)-1] {
+		if attempt.Success {
+			success++
+		} else {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		parts = append(parts, "failed "+times(failed))
+	}
+
+	if success > 0 {
+		parts = append(parts, "succeeded "+times(success))
+	}
+
+	last := w.Attempts[len(w.Attempts)-1]
+	outcome := "failed"
+
+	if last.Success {
+		outcome = "succeeded"
+	}
+
+	parts = append(parts, fmt.Sprintf("%s on %s attempt", outcome, ordinal(last.Number)))
+
+	return strings.Join(parts, ", ")
+}
+
+func times(n int) string {
+	switch n {
+	case 1:
+		return "once"
+	case 2:
+		return "twice"
+	}
+
+	return fmt.Sprintf("%d times", n)
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
 type Job struct {
 	ID    string
 	Name  string
@@ -56,6 +145,12 @@ type Job struct {
 
 type PipelineCounters struct {
 	Jobs, Works, Contexts, Success, Unknown, Errors, Executions int
+
+	// Works without execution, none was expected by schedule
+	Skipped int
+
+	// Works not checked, their executions could not be fetched
+	Unavailable int
 }
 
 type Pipeline struct {
@@ -74,8 +169,8 @@ type ReportLink struct {
 }
 
 type ReportLinkArguments struct {
-	Team, Date, Pipeline string
-	Status               []string
+	Team, Date, Pipeline, Owner string
+	Status                      []string
 }
 
 type Report struct {
@@ -84,6 +179,37 @@ type Report struct {
 	Filter    utils.Filter
 	Counters  PipelineCounters
 	Pipelines []Pipeline
+
+	// Pipelines skipped because their definition is invalid
+	InvalidPipelines []pipelines.Definition
+
+	// Data sources (djobi indices) in error, their works are DATA_UNAVAILABLE
+	Errors []ReportError
+}
+
+type ReportError struct {
+	Source, Message string
+}
+
+/**
+ * Add data source error, once per source & message.
+ */
+func (r *Report) AddError(err error) {
+	reportError := ReportError{Message: err.Error()}
+
+	var dataSourceError *executions.DataSourceError
+
+	if errors.As(err, &dataSourceError) {
+		reportError = ReportError{Source: dataSourceError.Source, Message: dataSourceError.Err.Error()}
+	}
+
+	for _, e := range r.Errors {
+		if e == reportError {
+			return
+		}
+	}
+
+	r.Errors = append(r.Errors, reportError)
 }
 
 /*
@@ -104,6 +230,10 @@ func (link *ReportLink) Build() string {
 		v.Set("pipeline", link.Arguments.Pipeline)
 	}
 
+	if len(link.Arguments.Owner) > 0 {
+		v.Set("owner", link.Arguments.Owner)
+	}
+
 	if len(link.Arguments.Status) > 0 {
 		v.Set("status", link.Arguments.Status[0])
 	}
@@ -138,9 +268,13 @@ func (r *Report) CalculateCounters() {
 					r.Counters.Errors++
 				} else if w.Status == constant.DoneUnknown {
 					r.Counters.Unknown++
+				} else if w.Status == constant.Skipped {
+					r.Counters.Skipped++
+				} else if w.Status == constant.DataUnavailable {
+					r.Counters.Unavailable++
 				}
 
-				if w.Status != constant.No {
+				if w.Status != constant.No && w.Status != constant.Skipped && w.Status != constant.DataUnavailable {
 					r.Counters.Executions++
 				}
 
@@ -154,12 +288,62 @@ func (r *Report) CalculateCounters() {
 		r.Counters.Errors,
 		r.Counters.Unknown,
 	)
+
+	if r.Counters.Unavailable > 0 {
+		r.Title += fmt.Sprintf(" / %d unavailable", r.Counters.Unavailable)
+	}
 }
 
 /**
  * Filter work by status (success , error ...).
  */
 func (r *Report) FilterByLevel(levels []string) *Report {
+	levelsMap := fixLevels(levels)
+
+	return r.FilterWorks(func(pipeline Pipeline, work Work) bool {
+		_, ok := levelsMap[work.Status]
+
+		return ok
+	})
+}
+
+/**
+ * Keep failing works (not success, not skipped) of pipelines owned by owner email,
+ * with one of the roles on the pipeline (any role if empty).
+ */
+func (r *Report) FilterFailingByOwner(email string, roles []string) *Report {
+	return r.FilterWorks(func(pipeline Pipeline, work Work) bool {
+		return pipeline.Definition.Meta.HasOwnerWithRole(email, roles) && !work.Success && work.Status != constant.Skipped
+	})
+}
+
+/**
+ * Owners emails of report pipelines, having one of the roles (any role if empty).
+ */
+func (r *Report) OwnersEmails(roles []string) []string {
+	var (
+		ret  []string
+		seen = make(map[string]bool)
+	)
+
+	for _, pipeline := range r.Pipelines {
+		for _, owner := range pipeline.Definition.Meta.OwnersByRole(roles) {
+			email := strings.ToLower(owner.Email)
+
+			if len(email) > 0 && !seen[email] {
+				seen[email] = true
+				ret = append(ret, email)
+			}
+		}
+	}
+
+	return ret
+}
+
+/**
+ * Copy report, keeping works matching the predicate.
+ */
+func (r *Report) FilterWorks(keep func(pipeline Pipeline, work Work) bool) *Report {
 	newReport := &Report{}
 
 	// Copy report (counters, title ...)
@@ -167,8 +351,8 @@ func (r *Report) FilterByLevel(levels []string) *Report {
 
 	// Reset pipelines list
 	newReport.Pipelines = []Pipeline{}
-
-	levelsMap := fixLevels(levels)
+	newReport.InvalidPipelines = r.InvalidPipelines
+	newReport.Errors = r.Errors
 
 	for _, pipeline := range r.Pipelines {
 		copyPipeline := Pipeline{
@@ -182,9 +366,13 @@ func (r *Report) FilterByLevel(levels []string) *Report {
 				Name: job.Name,
 			}
 			for _, work := range job.Works {
-				if _, ok := levelsMap[work.Status]; ok {
+				if keep(pipeline, work) {
 					copyJob.Works = append(copyJob.Works, work)
 					copyPipeline.Counters.Works++
+
+					if work.Status == constant.Skipped {
+						copyPipeline.Counters.Skipped++
+					}
 				}
 			}
 
@@ -216,6 +404,10 @@ func fixLevels(levels []string) map[string]string {
 			level = constant.DoneOk
 		} else if level == "WARNING" || level == "WARN" {
 			level = constant.DoneUnknown
+		} else if level == "SKIP" {
+			level = constant.Skipped
+		} else if level == "UNAVAILABLE" {
+			level = constant.DataUnavailable
 		}
 
 		ret[level] = level
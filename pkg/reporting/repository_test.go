@@ -0,0 +1,75 @@
+package reporting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datatok/tintin/pkg/pipelines"
+	"github.com/datatok/tintin/pkg/utils/constant"
+)
+
+func TestWork_RetrySummary(t *testing.T) {
+	attempts := func(successes ...bool) []Attempt {
+		var ret []Attempt
+
+		for i, success := range successes {
+			ret = append(ret, Attempt{Number: i + 1, Success: success})
+		}
+
+		return ret
+	}
+
+	tests := []struct {
+		name     string
+		attempts []Attempt
+		expected string
+	}{
+		{"no attempt", nil, ""},
+		{"single attempt", attempts(true), ""},
+		{"succeeded on retry", attempts(false, false, true), "failed twice, succeeded on 3rd attempt"},
+		{"failed again", attempts(false, false, false, false), "failed 3 times, failed on 4th attempt"},
+		{"failed after success", attempts(true, false), "succeeded once, failed on 2nd attempt"},
+		{"mixed", attempts(false, true, false, true, false, false, false, false, false, false, true), "failed 8 times, succeeded twice, succeeded on 11th attempt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Work{Attempts: tt.attempts}.RetrySummary())
+		})
+	}
+}
+
+func TestReport_FilterFailingByOwner(t *testing.T) {
+	pipeline := func(name, role string) Pipeline {
+		return Pipeline{
+			Definition: pipelines.Definition{
+				FullName: name,
+				Meta: pipelines.MetaDefinition{
+					Owners: []pipelines.MetaOwnerDefinition{{Name: "bob", Email: "Bob@localhost", Role: role}},
+				},
+			},
+			Jobs: []Job{{Name: "daily", Works: []Work{
+				{Name: "fr", Status: constant.DoneError},
+				{Name: "de", Status: constant.DoneOk, Success: true},
+			}}},
+		}
+	}
+
+	report := &Report{Pipelines: []Pipeline{pipeline("team_a/conso", "oncall"), pipeline("team_a/archivr", "developer")}}
+
+	names := func(report *Report) []string {
+		var ret []string
+
+		for _, pipeline := range report.Pipelines {
+			ret = append(ret, pipeline.Definition.FullName)
+		}
+
+		return ret
+	}
+
+	assert.Equal(t, []string{"bob@localhost"}, report.OwnersEmails([]string{"oncall"}))
+	assert.Equal(t, []string{"team_a/conso"}, names(report.FilterFailingByOwner("bob@localhost", []string{"oncall"})), "only pipelines where the owner has the role")
+	assert.Equal(t, []string{"team_a/conso", "team_a/archivr"}, names(report.FilterFailingByOwner("bob@localhost", nil)))
+	assert.Len(t, report.FilterFailingByOwner("bob@localhost", nil).Pipelines[0].Jobs[0].Works, 1, "only failing works")
+}
@@ -8,4 +8,7 @@ const (
 	Skipped     = "SKIPPED"
 	Todo        = "TODO"
 	InProgress  = "IN_PROGRESS"
+
+	// Executions could not be fetched (elasticsearch down or in error)
+	DataUnavailable = "DATA_UNAVAILABLE"
 )
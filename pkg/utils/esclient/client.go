@@ -0,0 +1,116 @@
+package esclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+/**
+ * Build elasticsearch client of URLs (comma separated), with connection settings
+ * shared by djobi stores & report store: credentials, TLS, timeouts & retries.
+ */
+func New(settings *cli.EnvSettings, urls string) (*elasticsearch.Client, error) {
+	password, err := secret(settings.ElasticsearchPassword, settings.ElasticsearchPasswordFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := secret(settings.ElasticsearchAPIKey, settings.ElasticsearchAPIKeyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := secret(settings.ElasticsearchBearerToken, settings.ElasticsearchBearerTokenFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(settings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:    strings.Split(urls, ","),
+		Username:     settings.ElasticsearchUsername,
+		Password:     password,
+		APIKey:       apiKey,
+		ServiceToken: token,
+		MaxRetries:   settings.ElasticsearchMaxRetries,
+		DisableRetry: settings.ElasticsearchMaxRetries <= 0,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: settings.ElasticsearchTimeout}).DialContext,
+			MaxIdleConnsPerHost:   10,
+			ResponseHeaderTimeout: settings.ElasticsearchTimeout,
+			TLSHandshakeTimeout:   settings.ElasticsearchTimeout,
+			TLSClientConfig:       tlsConfig,
+		},
+	})
+}
+
+func newTLSConfig(settings *cli.EnvSettings) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: settings.ElasticsearchInsecure, //nolint:gosec // explicit opt-in
+	}
+
+	if len(settings.ElasticsearchCACert) > 0 {
+		dat, err := os.ReadFile(settings.ElasticsearchCACert)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to read elasticsearch CA bundle: %w", err)
+		}
+
+		config.RootCAs, _ = x509.SystemCertPool()
+
+		if config.RootCAs == nil {
+			config.RootCAs = x509.NewCertPool()
+		}
+
+		if !config.RootCAs.AppendCertsFromPEM(dat) {
+			return nil, fmt.Errorf("no certificate found in elasticsearch CA bundle %s", settings.ElasticsearchCACert)
+		}
+	}
+
+	if len(settings.ElasticsearchClientCert) > 0 || len(settings.ElasticsearchClientKey) > 0 {
+		cert, err := tls.LoadX509KeyPair(settings.ElasticsearchClientCert, settings.ElasticsearchClientKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to load elasticsearch client certificate: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+/**
+ * Secret value, or the content of its file.
+ */
+func secret(value, file string) (string, error) {
+	if len(file) == 0 {
+		return value, nil
+	}
+
+	dat, err := os.ReadFile(file)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to read secret file: %w", err)
+	}
+
+	return strings.TrimSpace(string(dat)), nil
+}
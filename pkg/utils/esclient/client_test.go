@@ -0,0 +1,85 @@
+package esclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datatok/tintin/pkg/utils/cli"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+
+	require.NoError(t, os.WriteFile(passwordFile, []byte("s3cret\n"), 0600))
+
+	var authorization string
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	settings := &cli.EnvSettings{
+		ElasticsearchUsername:     "tintin",
+		ElasticsearchPasswordFile: passwordFile,
+		ElasticsearchTimeout:      time.Second,
+	}
+
+	t.Run("verify certificates by default", func(t *testing.T) {
+		client, err := New(settings, server.URL)
+		require.NoError(t, err)
+
+		_, err = client.Info()
+		assert.Error(t, err)
+	})
+
+	t.Run("basic auth, password from file", func(t *testing.T) {
+		insecure := *settings
+		insecure.ElasticsearchInsecure = true
+
+		client, err := New(&insecure, server.URL)
+		require.NoError(t, err)
+
+		res, err := client.Info()
+
+		if assert.NoError(t, err) {
+			res.Body.Close()
+
+			user, password, ok := (&http.Request{Header: http.Header{"Authorization": {authorization}}}).BasicAuth()
+
+			assert.True(t, ok)
+			assert.Equal(t, "tintin", user)
+			assert.Equal(t, "s3cret", password)
+		}
+	})
+
+	t.Run("invalid CA bundle", func(t *testing.T) {
+		withCA := *settings
+		withCA.ElasticsearchCACert = passwordFile
+
+		_, err := New(&withCA, server.URL)
+
+		assert.EqualError(t, err, "no certificate found in elasticsearch CA bundle "+passwordFile)
+	})
+
+	t.Run("missing secret file", func(t *testing.T) {
+		missing := *settings
+		missing.ElasticsearchAPIKeyFile = filepath.Join(dir, "nope")
+
+		_, err := New(&missing, server.URL)
+
+		assert.Error(t, err)
+	})
+}
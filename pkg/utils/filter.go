@@ -1,8 +1,14 @@
 package utils
 
 type Filter struct {
-	Schedule, Team, Pipelines string
-	Status                    []string
+	Schedule, Team string
+	Status         []string
+
+	// Globs on pipeline full name, "!" prefix to exclude, "re:" prefix for regex
+	Pipelines []string
+
+	// Owner name or email, from pipeline meta.owners
+	Owner string
 }
 
 type ExecutionTimeline struct {
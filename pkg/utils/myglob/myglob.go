@@ -6,6 +6,7 @@ package myglob
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -60,3 +61,69 @@ func (globs Globs) Expand() ([]string, error) {
 
 	return matches, nil
 }
+
+// Match reports whether name matches the "**" aware glob pattern.
+// "*" and "?" never match "/", "**" matches zero-or-more directory levels.
+func Match(pattern, name string) (bool, error) {
+	re, err := Compile(pattern)
+
+	if err != nil {
+		return false, err
+	}
+
+	return re.MatchString(name), nil
+}
+
+// Compile translates the glob pattern to an anchored regular expression.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	var buffer strings.Builder
+
+	buffer.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			buffer.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			buffer.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			buffer.WriteString(".*")
+			i++
+		case c == '*':
+			buffer.WriteString("[^/]*")
+		case c == '?':
+			buffer.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+
+			if end < 0 {
+				return nil, filepath.ErrBadPattern
+			}
+
+			class := pattern[i+1 : i+1+end]
+
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			buffer.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			buffer.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	buffer.WriteString("$")
+
+	re, err := regexp.Compile(buffer.String())
+
+	if err != nil {
+		return nil, filepath.ErrBadPattern
+	}
+
+	return re, nil
+}
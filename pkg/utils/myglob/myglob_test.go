@@ -0,0 +1,44 @@
+package myglob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"team_a/*", "team_a/archivr", true},
+		{"team_a/*", "team_a/archivr/daily", false},
+		{"team_a/**", "team_a/archivr/daily", true},
+		{"team_a/**", "team_a", true},
+		{"**/archivr", "team_b/archivr", true},
+		{"**/archivr", "archivr", true},
+		{"**/sandbox/*", "team_a/sandbox/test", true},
+		{"**/dev*/**", "team_a/develop/x", true},
+		{"**/dev*/**", "team_a/archivr", false},
+		{"team_?/conso", "team_a/conso", true},
+		{"team_[!a]/*", "team_a/conso", false},
+		{"team_[!a]/*", "team_b/conso", true},
+		{"team.a", "teamXa", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			match, err := Match(tt.pattern, tt.name)
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.match, match)
+			}
+		})
+	}
+
+	t.Run("bad pattern", func(t *testing.T) {
+		_, err := Match("team_[a", "team_a")
+
+		assert.Error(t, err)
+	})
+}